@@ -14,10 +14,18 @@ import (
 
 // GetAccountBalance fetches account balance
 func (c *Client) GetAccountBalance(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountBalanceResponse, err error) {
+	if cached, ok := c.cachedAccount("balance", params); ok {
+		return cached.(structs.GetAccountBalanceResponse), nil
+	}
+
 	resp.Height = params.Height
 
-	balResp, err := c.bankClient.AllBalances(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
-		&types.QueryAllBalancesRequest{Address: params.Account})
+	var balResp *types.QueryAllBalancesResponse
+	err = c.call(ctx, "AllBalances", params.Height, func(n *node) (err error) {
+		balResp, err = n.bankClient.AllBalances(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
+			&types.QueryAllBalancesRequest{Address: params.Account})
+		return err
+	})
 	if err != nil {
 		return resp, fmt.Errorf("[COSMOS-API] Error fetching balances: %w", err)
 	}
@@ -32,5 +40,6 @@ func (c *Client) GetAccountBalance(ctx context.Context, params structs.HeightAcc
 		)
 	}
 
-	return resp, err
+	c.cacheAccount("balance", params, resp)
+	return resp, nil
 }
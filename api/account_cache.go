@@ -0,0 +1,90 @@
+package api
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/figment-networks/indexer-manager/structs"
+)
+
+const defaultAccountCacheSize = 10000
+
+// accountKey identifies account query result
+type accountKey struct {
+	query   string
+	account string
+	height  uint64
+}
+
+type accountEntry struct {
+	key   accountKey
+	value interface{}
+}
+
+// accountCache in memory LRU cache of account query results at past heights.
+// State at given height never changes once block is committed, so entries don't expire.
+type accountCache struct {
+	l        sync.Mutex
+	capacity int
+	lru      *list.List
+	entries  map[accountKey]*list.Element
+}
+
+func newAccountCache(capacity int) *accountCache {
+	if capacity == 0 {
+		capacity = defaultAccountCacheSize
+	}
+	return &accountCache{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[accountKey]*list.Element),
+	}
+}
+
+func (ac *accountCache) get(key accountKey) (v interface{}, ok bool) {
+	ac.l.Lock()
+	defer ac.l.Unlock()
+
+	el, ok := ac.entries[key]
+	if !ok {
+		accountCacheRequests.WithLabels(key.query, "miss").Inc()
+		return nil, false
+	}
+	accountCacheRequests.WithLabels(key.query, "hit").Inc()
+	ac.lru.MoveToFront(el)
+	return el.Value.(accountEntry).value, true
+}
+
+func (ac *accountCache) add(key accountKey, v interface{}) {
+	ac.l.Lock()
+	defer ac.l.Unlock()
+
+	if el, ok := ac.entries[key]; ok {
+		ac.lru.MoveToFront(el)
+		return
+	}
+	ac.entries[key] = ac.lru.PushFront(accountEntry{key: key, value: v})
+
+	for ac.lru.Len() > ac.capacity {
+		ae := ac.lru.Remove(ac.lru.Back()).(accountEntry)
+		delete(ac.entries, ae.key)
+		accountCacheEvictions.WithLabels().Inc()
+	}
+}
+
+// cachedAccount returns cached result of account query
+func (c *Client) cachedAccount(query string, params structs.HeightAccount) (v interface{}, ok bool) {
+	if c.accounts.capacity < 0 || params.Height == 0 {
+		return nil, false
+	}
+	return c.accounts.get(accountKey{query, params.Account, params.Height})
+}
+
+// cacheAccount caches result of account query, if it's made at height below the latest block.
+// Cached values are shared between callers and must not be modified.
+func (c *Client) cacheAccount(query string, params structs.HeightAccount, v interface{}) {
+	if c.accounts.capacity < 0 || params.Height == 0 || params.Height >= c.pool.latestHeight() {
+		return
+	}
+	c.accounts.add(accountKey{query, params.Account, params.Height}, v)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountCache(t *testing.T) {
+	InitMetrics()
+
+	ac := newAccountCache(2)
+	ac.add(accountKey{"balance", "a", 1}, 1)
+	ac.add(accountKey{"balance", "a", 2}, 2)
+
+	v, ok := ac.get(accountKey{"balance", "a", 1})
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	_, ok = ac.get(accountKey{"reward", "a", 1})
+	require.False(t, ok)
+
+	ac.add(accountKey{"balance", "a", 3}, 3)
+	_, ok = ac.get(accountKey{"balance", "a", 2})
+	require.False(t, ok)
+	_, ok = ac.get(accountKey{"balance", "a", 1})
+	require.True(t, ok)
+	_, ok = ac.get(accountKey{"balance", "a", 3})
+	require.True(t, ok)
+}
+
+func TestClientAccountCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		cacheSize  int
+		height     uint64
+		wantServed int64
+	}{
+		{name: "past height cached", height: 5, wantServed: 1},
+		{name: "latest height not cached", height: 10, wantServed: 2},
+		{name: "height 0 not cached", height: 0, wantServed: 2},
+		{name: "cache disabled", cacheSize: -1, height: 5, wantServed: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 10})
+			cli := newTestClient(t, &ClientConfig{AccountCacheSize: tt.cacheSize}, fn)
+			ctx := context.Background()
+
+			_, err := cli.GetBlock(ctx, structs.HeightHash{})
+			require.NoError(t, err)
+			served := fn.served()
+
+			params := structs.HeightAccount{Height: tt.height, Account: "cosmos1account"}
+			first, err := cli.GetAccountBalance(ctx, params)
+			require.NoError(t, err)
+			second, err := cli.GetAccountBalance(ctx, params)
+			require.NoError(t, err)
+
+			require.Equal(t, first, second)
+			require.Equal(t, tt.wantServed, fn.served()-served)
+		})
+	}
+}
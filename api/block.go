@@ -3,15 +3,24 @@ package api
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	"github.com/figment-networks/indexer-manager/structs"
 	"github.com/tendermint/tendermint/libs/bytes"
-	"google.golang.org/grpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
 )
 
+// ErrBlockNotFound is returned when node doesn't know block of given hash
+var ErrBlockNotFound = status.Error(codes.NotFound, "block not found")
+
 // BlocksMap map of blocks to control block map
 // with extra summary of number of transactions
 type BlocksMap struct {
@@ -27,30 +36,41 @@ type BlockErrorPair struct {
 	Err    error
 }
 
-// GetBlock fetches most recent block from chain
+// GetBlock fetches block of given height or hash from chain, the most recent one when neither is set
 func (c *Client) GetBlock(ctx context.Context, params structs.HeightHash) (block structs.Block, er error) {
 	var ok bool
-	if params.Height != 0 {
-		block, ok = c.Sbc.Get(params.Height)
-		if ok {
-			return block, nil
+	switch {
+	case params.Height != 0:
+		if block, ok = c.Cache.Get(params.Height); !ok {
+			if block, ok = c.DiskCache.Block(params.Height); ok {
+				c.Cache.Add(block)
+			}
 		}
+	case params.Hash != "":
+		if block, ok = c.Cache.GetByHash(params.Hash); !ok {
+			return c.fetchBlockByHash(ctx, params.Hash)
+		}
+	default:
+		block, ok = c.Cache.Latest()
 	}
-
-	if err := c.rateLimiterGRPC.Wait(ctx); err != nil {
-		return block, err
+	if ok {
+		return block, nil
 	}
 
-	nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
-	defer cancel()
-	n := time.Now()
 	if params.Height == 0 {
-		lb, err := c.tmServiceClient.GetLatestBlock(nctx, &tmservice.GetLatestBlockRequest{})
+		var lb *tmservice.GetLatestBlockResponse
+		err := c.call(ctx, "GetLatestBlock", 0, func(n *node) (err error) {
+			nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+			defer cancel()
+			lb, err = n.tmServiceClient.GetLatestBlock(nctx, &tmservice.GetLatestBlockRequest{})
+			if err == nil {
+				n.setLatestHeight(uint64(lb.Block.Header.Height))
+			}
+			return err
+		})
 		if err != nil {
-			rawRequestGRPCDuration.WithLabels("GetLatestBlock", "error").Observe(time.Since(n).Seconds())
 			return block, err
 		}
-		rawRequestGRPCDuration.WithLabels("GetLatestBlock", "ok").Observe(time.Since(n).Seconds())
 
 		bh := bytes.HexBytes(lb.BlockId.Hash)
 
@@ -61,17 +81,56 @@ func (c *Client) GetBlock(ctx context.Context, params structs.HeightHash) (block
 			ChainID:              lb.Block.Header.ChainID,
 			NumberOfTransactions: uint64(len(lb.Block.Data.Txs)),
 		}
-		c.Sbc.Add(block)
+		c.Cache.AddLatest(block)
 
 		return block, nil
 	}
 
-	bbh, err := c.tmServiceClient.GetBlockByHeight(nctx, &tmservice.GetBlockByHeightRequest{Height: int64(params.Height)}, grpc.WaitForReady(true))
+	block, _, _, err := c.fetchBlock(ctx, params.Height)
+	return block, err
+}
+
+// fetchBlockByHash gets block of given hash from node. Grpc can't query blocks by hash,
+// height of the block is looked up in tendermint rpc (without it block is not found).
+func (c *Client) fetchBlockByHash(ctx context.Context, hash string) (block structs.Block, err error) {
+	if len(c.rpcAddrs) == 0 {
+		return block, ErrBlockNotFound
+	}
+
+	res := apiTypes.ResultBlock{}
+	if err := c.rpcGet(ctx, "block_by_hash", url.Values{"hash": {"0x" + hash}}, &res); err != nil {
+		return block, err
+	}
+	if res.Block.Header.Height == "" { // node doesn't know the hash
+		return block, ErrBlockNotFound
+	}
+	height, err := strconv.ParseUint(res.Block.Header.Height, 10, 64)
+	if err != nil {
+		return block, fmt.Errorf("error parsing height of block %s: %w", hash, err)
+	}
+
+	block, _, _, err = c.fetchBlock(ctx, height)
 	if err != nil {
-		rawRequestGRPCDuration.WithLabels("GetBlockByHeight", "error").Observe(time.Since(n).Seconds())
 		return block, err
 	}
-	rawRequestGRPCDuration.WithLabels("GetBlockByHeight", "ok").Observe(time.Since(n).Seconds())
+	if !strings.EqualFold(block.Hash, hash) { // reorganized in the meantime
+		return structs.Block{}, ErrBlockNotFound
+	}
+	return block, nil
+}
+
+// fetchBlock gets block of given height from node, caching it with its header and signatures
+func (c *Client) fetchBlock(ctx context.Context, height uint64) (block structs.Block, header BlockHeader, sigs BlockSignatures, err error) {
+	var bbh *tmservice.GetBlockByHeightResponse
+	err = c.call(ctx, "GetBlockByHeight", height, func(n *node) (err error) {
+		nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+		defer cancel()
+		bbh, err = n.tmServiceClient.GetBlockByHeight(nctx, &tmservice.GetBlockByHeightRequest{Height: int64(height)}, c.pool.waitForReady())
+		return err
+	})
+	if err != nil {
+		return block, header, sigs, err
+	}
 
 	hb := bytes.HexBytes(bbh.BlockId.Hash)
 	block = structs.Block{
@@ -81,11 +140,30 @@ func (c *Client) GetBlock(ctx context.Context, params structs.HeightHash) (block
 		ChainID:              bbh.Block.Header.ChainID,
 		NumberOfTransactions: uint64(len(bbh.Block.Data.Txs)),
 	}
+	header = blockHeader(bbh.Block)
+	sigs = blockSignatures(bbh.Block)
+
+	c.Cache.Add(block)
+	c.Cache.AddHeader(block.Height, header)
+	c.Cache.AddSignatures(block.Height, sigs)
+	if c.cfg.TxSource == TxSourceBlock {
+		txs := bbh.Block.Data.Txs
+		if txs == nil {
+			txs = [][]byte{}
+		}
+		c.Cache.AddTxs(block.Height, txs)
+	}
+	if err := c.DiskCache.PutBlock(block); err != nil {
+		c.logger.Warn("[COSMOS-API] Error storing block in disk cache", zap.Uint64("height", block.Height), zap.Error(err))
+	}
+	if err := c.DiskCache.PutHeader(header); err != nil {
+		c.logger.Warn("[COSMOS-API] Error storing block header in disk cache", zap.Uint64("height", block.Height), zap.Error(err))
+	}
+	if err := c.DiskCache.PutSignatures(block.Height, sigs); err != nil {
+		c.logger.Warn("[COSMOS-API] Error storing signatures in disk cache", zap.Uint64("height", block.Height), zap.Error(err))
+	}
 
-	c.Sbc.Add(block)
-
-	return block, nil
-
+	return block, header, sigs, nil
 }
 
 func (c Client) GetBlockAsync(ctx context.Context, in chan uint64, out chan<- BlockErrorPair) {
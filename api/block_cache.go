@@ -1,54 +1,277 @@
 package api
 
 import (
+	"container/list"
 	"sync"
+	"time"
 
 	"github.com/figment-networks/indexer-manager/structs"
+
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
+)
+
+const (
+	defaultBlockCacheSize   = 400
+	defaultBlockCacheTipTTL = time.Second
 )
 
-// SimpleBlockCache simple in memory block cache to store latest blocks
-type SimpleBlockCache struct {
-	space  map[uint64]structs.Block
-	blocks chan *structs.Block
-	l      sync.RWMutex
+// cachedBlock is block with its header, signatures, raw transactions and block results (if known)
+type cachedBlock struct {
+	block   structs.Block
+	header  *BlockHeader
+	sigs    *BlockSignatures
+	txs     [][]byte
+	results *apiTypes.ResultBlockResults
 }
 
-// NewSimpleBlockCache a SimpleBlockCache constructor
-func NewSimpleBlockCache(cap int) *SimpleBlockCache {
-	return &SimpleBlockCache{
-		space:  make(map[uint64]structs.Block),
-		blocks: make(chan *structs.Block, cap),
+// BlockCache in memory LRU cache of blocks indexed by height and hash.
+// The latest block is kept separately for tipTTL, as it changes with every new block.
+type BlockCache struct {
+	l        sync.Mutex
+	capacity int
+	lru      *list.List
+	heights  map[uint64]*list.Element
+	hashes   map[string]*list.Element
+
+	tipTTL time.Duration
+	tip    structs.Block
+	tipAt  time.Time
+}
+
+// NewBlockCache a BlockCache constructor, negative tipTTL disables caching of the latest block
+func NewBlockCache(capacity int, tipTTL time.Duration) *BlockCache {
+	if capacity <= 0 {
+		capacity = defaultBlockCacheSize
+	}
+	if tipTTL == 0 {
+		tipTTL = defaultBlockCacheTipTTL
+	}
+	return &BlockCache{
+		capacity: capacity,
+		lru:      list.New(),
+		heights:  make(map[uint64]*list.Element),
+		hashes:   make(map[string]*list.Element),
+		tipTTL:   tipTTL,
 	}
 }
 
-// Add blocks to the cache (thread safe)
-func (sbc *SimpleBlockCache) Add(bl structs.Block) {
-	sbc.l.Lock()
-	defer sbc.l.Unlock()
+// Add block to the cache (thread safe)
+func (bc *BlockCache) Add(bl structs.Block) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
 
-	_, ok := sbc.space[bl.Height]
-	if ok {
+	if el, ok := bc.heights[bl.Height]; ok {
+		// block at height may differ after reorg
+		cb := el.Value.(cachedBlock)
+		delete(bc.hashes, cb.block.Hash)
+		if cb.block.Hash != bl.Hash {
+			cb.header, cb.sigs, cb.txs, cb.results = nil, nil, nil, nil
+		}
+		cb.block = bl
+		el.Value = cb
+		if bl.Hash != "" {
+			bc.hashes[bl.Hash] = el
+		}
+		bc.lru.MoveToFront(el)
 		return
 	}
 
-	sbc.space[bl.Height] = bl
-	select {
-	case sbc.blocks <- &bl:
-	default:
-		oldBlock := <-sbc.blocks
-		if oldBlock != nil {
-			delete(sbc.space, oldBlock.Height)
+	el := bc.lru.PushFront(cachedBlock{block: bl})
+	bc.heights[bl.Height] = el
+	if bl.Hash != "" {
+		bc.hashes[bl.Hash] = el
+	}
+
+	for bc.lru.Len() > bc.capacity {
+		bc.remove(bc.lru.Back())
+		blockCacheEvictions.WithLabels().Inc()
+	}
+}
+
+// AddHeader adds header to cached block of given height
+func (bc *BlockCache) AddHeader(height uint64, header BlockHeader) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	if el, ok := bc.heights[height]; ok {
+		cb := el.Value.(cachedBlock)
+		cb.header = &header
+		el.Value = cb
+	}
+}
+
+// Header returns header of cached block of given height (thread safe)
+func (bc *BlockCache) Header(height uint64) (header BlockHeader, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	el, ok := bc.heights[height]
+	if !ok || el.Value.(cachedBlock).header == nil {
+		blockCacheRequests.WithLabels("header", "miss").Inc()
+		return header, false
+	}
+	blockCacheRequests.WithLabels("header", "hit").Inc()
+	bc.lru.MoveToFront(el)
+	return *el.Value.(cachedBlock).header, true
+}
+
+// AddSignatures adds signatures to cached block of given height
+func (bc *BlockCache) AddSignatures(height uint64, sigs BlockSignatures) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	if el, ok := bc.heights[height]; ok {
+		cb := el.Value.(cachedBlock)
+		cb.sigs = &sigs
+		el.Value = cb
+	}
+}
+
+// Signatures returns signatures of cached block of given height (thread safe)
+func (bc *BlockCache) Signatures(height uint64) (sigs BlockSignatures, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	el, ok := bc.heights[height]
+	if !ok || el.Value.(cachedBlock).sigs == nil {
+		blockCacheRequests.WithLabels("signatures", "miss").Inc()
+		return sigs, false
+	}
+	blockCacheRequests.WithLabels("signatures", "hit").Inc()
+	bc.lru.MoveToFront(el)
+	return *el.Value.(cachedBlock).sigs, true
+}
+
+// AddTxs adds raw transactions (block data) to cached block of given height
+func (bc *BlockCache) AddTxs(height uint64, txs [][]byte) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	if el, ok := bc.heights[height]; ok {
+		cb := el.Value.(cachedBlock)
+		cb.txs = txs
+		el.Value = cb
+	}
+}
+
+// Txs returns raw transactions of cached block of given height (thread safe)
+func (bc *BlockCache) Txs(height uint64) (txs [][]byte, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	el, ok := bc.heights[height]
+	if !ok || el.Value.(cachedBlock).txs == nil {
+		blockCacheRequests.WithLabels("txs", "miss").Inc()
+		return nil, false
+	}
+	blockCacheRequests.WithLabels("txs", "hit").Inc()
+	bc.lru.MoveToFront(el)
+	return el.Value.(cachedBlock).txs, true
+}
+
+// AddResults adds block results (from tendermint rpc) to cached block of given height,
+// they are shared by transactions decoded from block and block events
+func (bc *BlockCache) AddResults(height uint64, results apiTypes.ResultBlockResults) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	if el, ok := bc.heights[height]; ok {
+		cb := el.Value.(cachedBlock)
+		cb.results = &results
+		el.Value = cb
+	}
+}
+
+// Results returns block results of cached block of given height (thread safe)
+func (bc *BlockCache) Results(height uint64) (results apiTypes.ResultBlockResults, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	el, ok := bc.heights[height]
+	if !ok || el.Value.(cachedBlock).results == nil {
+		blockCacheRequests.WithLabels("results", "miss").Inc()
+		return results, false
+	}
+	blockCacheRequests.WithLabels("results", "hit").Inc()
+	bc.lru.MoveToFront(el)
+	return *el.Value.(cachedBlock).results, true
+}
+
+// AddLatest adds the latest block of the chain
+func (bc *BlockCache) AddLatest(bl structs.Block) {
+	bc.Add(bl)
+
+	bc.l.Lock()
+	defer bc.l.Unlock()
+	bc.tip = bl
+	bc.tipAt = time.Now()
+}
+
+// Get block of given height (thread safe)
+func (bc *BlockCache) Get(height uint64) (bl structs.Block, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	return bc.get(bc.heights[height], "height")
+}
+
+// GetByHash gets block of given hash (thread safe)
+func (bc *BlockCache) GetByHash(hash string) (bl structs.Block, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	return bc.get(bc.hashes[hash], "hash")
+}
+
+// Latest returns the latest block if it's not older than tipTTL (thread safe)
+func (bc *BlockCache) Latest() (bl structs.Block, ok bool) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	if bc.tipAt.IsZero() || time.Since(bc.tipAt) > bc.tipTTL {
+		blockCacheRequests.WithLabels("latest", "miss").Inc()
+		return bl, false
+	}
+	blockCacheRequests.WithLabels("latest", "hit").Inc()
+	return bc.tip, true
+}
+
+// InvalidateFrom removes blocks of given height and above (thread safe)
+func (bc *BlockCache) InvalidateFrom(height uint64) {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+
+	for h, el := range bc.heights {
+		if h >= height {
+			bc.remove(el)
 		}
-		sbc.blocks <- &bl
 	}
+	if bc.tip.Height >= height {
+		bc.tip, bc.tipAt = structs.Block{}, time.Time{}
+	}
+}
 
+// Len returns number of cached blocks
+func (bc *BlockCache) Len() int {
+	bc.l.Lock()
+	defer bc.l.Unlock()
+	return bc.lru.Len()
 }
 
-// Get and check block of given height (thread safe)
-func (sbc *SimpleBlockCache) Get(height uint64) (bl structs.Block, ok bool) {
-	sbc.l.RLock()
-	defer sbc.l.RUnlock()
+func (bc *BlockCache) get(el *list.Element, key string) (bl structs.Block, ok bool) {
+	if el == nil {
+		blockCacheRequests.WithLabels(key, "miss").Inc()
+		return bl, false
+	}
+	blockCacheRequests.WithLabels(key, "hit").Inc()
+	bc.lru.MoveToFront(el)
+	return el.Value.(cachedBlock).block, true
+}
 
-	bl, ok = sbc.space[bl.Height]
-	return bl, ok
+func (bc *BlockCache) remove(el *list.Element) {
+	bl := bc.lru.Remove(el).(cachedBlock).block
+	delete(bc.heights, bl.Height)
+	if bc.hashes[bl.Hash] == el {
+		delete(bc.hashes, bl.Hash)
+	}
 }
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+func testBlock(height uint64) structs.Block {
+	return structs.Block{Height: height, Hash: "HASH" + strconv.FormatUint(height, 10)}
+}
+
+func TestBlockCache(t *testing.T) {
+	InitMetrics()
+
+	t.Run("get by height and hash", func(t *testing.T) {
+		bc := NewBlockCache(10, 0)
+		bc.Add(testBlock(5))
+
+		bl, ok := bc.Get(5)
+		require.True(t, ok)
+		require.Equal(t, testBlock(5), bl)
+
+		bl, ok = bc.GetByHash("HASH5")
+		require.True(t, ok)
+		require.Equal(t, testBlock(5), bl)
+
+		_, ok = bc.Get(6)
+		require.False(t, ok)
+		_, ok = bc.GetByHash("HASH6")
+		require.False(t, ok)
+	})
+
+	t.Run("least recently used evicted", func(t *testing.T) {
+		bc := NewBlockCache(3, 0)
+		bc.Add(testBlock(1))
+		bc.Add(testBlock(2))
+		bc.Add(testBlock(3))
+
+		_, ok := bc.Get(1)
+		require.True(t, ok)
+		bc.Add(testBlock(4))
+
+		require.Equal(t, 3, bc.Len())
+		_, ok = bc.Get(2)
+		require.False(t, ok)
+		_, ok = bc.GetByHash("HASH2")
+		require.False(t, ok)
+		for _, h := range []uint64{1, 3, 4} {
+			_, ok = bc.Get(h)
+			require.True(t, ok, h)
+		}
+	})
+
+	t.Run("replaced block at height", func(t *testing.T) {
+		bc := NewBlockCache(3, 0)
+		bc.Add(testBlock(1))
+		bc.Add(structs.Block{Height: 1, Hash: "OTHER"})
+
+		require.Equal(t, 1, bc.Len())
+		_, ok := bc.GetByHash("HASH1")
+		require.False(t, ok)
+		bl, ok := bc.GetByHash("OTHER")
+		require.True(t, ok)
+		require.Equal(t, uint64(1), bl.Height)
+	})
+
+	t.Run("latest expires", func(t *testing.T) {
+		bc := NewBlockCache(3, 50*time.Millisecond)
+		_, ok := bc.Latest()
+		require.False(t, ok)
+
+		bc.AddLatest(testBlock(7))
+		bl, ok := bc.Latest()
+		require.True(t, ok)
+		require.Equal(t, testBlock(7), bl)
+
+		time.Sleep(60 * time.Millisecond)
+		_, ok = bc.Latest()
+		require.False(t, ok)
+
+		_, ok = bc.Get(7)
+		require.True(t, ok)
+	})
+
+	t.Run("latest not cached", func(t *testing.T) {
+		bc := NewBlockCache(3, -1)
+		bc.AddLatest(testBlock(7))
+		_, ok := bc.Latest()
+		require.False(t, ok)
+	})
+}
+
+func TestClientBlockCache(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{BlockCacheSize: 2, BlockCacheTipTTL: time.Minute}, fn)
+	ctx := context.Background()
+
+	b5, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	bl, err := cli.GetBlock(ctx, structs.HeightHash{Hash: b5.Hash})
+	require.NoError(t, err)
+	require.Equal(t, b5, bl)
+	require.Equal(t, int64(1), fn.served())
+
+	latest, err := cli.GetBlock(ctx, structs.HeightHash{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), latest.Height)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{})
+	require.NoError(t, err)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), fn.served())
+
+	// capacity of 2, block 5 is evicted
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 6})
+	require.NoError(t, err)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), fn.served())
+}
+
+func TestClientGetBlockByHash(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	// tendermint rpc knows blocks of the fake chain by hash
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/block_by_hash" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		hash, _ := hex.DecodeString(strings.TrimPrefix(r.URL.Query().Get("hash"), "0x"))
+		var height uint64
+		if _, err := fmt.Sscanf(string(hash), "block-%d", &height); err != nil || int64(height) > chain.height {
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":-1,"result":{"block_id":{"hash":""},"block":null}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"block":{"header":{"chain_id":"test-1","height":"%d"}}}}`, height)
+	}))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	cli := newTestClient(t, &ClientConfig{TendermintRPCAddr: srv.URL}, fn)
+	b7, err := cli.GetBlock(ctx, structs.HeightHash{Hash: bytes.HexBytes("block-7").String()})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), b7.Height)
+	require.Equal(t, bytes.HexBytes("block-7").String(), b7.Hash)
+
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Hash: bytes.HexBytes("block-70").String()})
+	require.Equal(t, ErrBlockNotFound, err)
+
+	// without tendermint rpc block not cached is not found, it's never the latest one
+	cli = newTestClient(t, &ClientConfig{}, fn)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Hash: bytes.HexBytes("block-7").String()})
+	require.Equal(t, ErrBlockNotFound, err)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/figment-networks/indexer-manager/structs"
+
+	"github.com/figment-networks/cosmos-worker/api/mapper"
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
+)
+
+// Phases of the block in which block events are emitted
+const (
+	PhaseBeginBlock = "begin_block"
+	PhaseEndBlock   = "end_block"
+)
+
+// BlockEvent is event emitted outside of transactions, in BeginBlock or EndBlock
+// (minting, proposer rewards, slashing, unbonding completions, proposal results...)
+type BlockEvent struct {
+	Height    uint64              `json:"height"`
+	BlockHash string              `json:"block_hash"`
+	ChainID   string              `json:"chain_id"`
+	Time      time.Time           `json:"time"`
+	Phase     string              `json:"phase"`
+	Index     int                 `json:"index"`
+	Event     structs.SubsetEvent `json:"event"`
+}
+
+// GetBlockEvents fetches events emitted in BeginBlock and EndBlock of the block from tendermint rpc.
+// Returns no events when tendermint rpc address is not configured.
+func (c *Client) GetBlockEvents(ctx context.Context, block structs.Block) (evs []BlockEvent, err error) {
+	if len(c.rpcAddrs) == 0 {
+		return nil, nil
+	}
+
+	res, err := c.getBlockResults(ctx, block.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	for phase, events := range [][]apiTypes.ABCIEvent{res.BeginBlockEvents, res.EndBlockEvents} {
+		for _, ev := range events {
+			se := types.StringEvent{Type: ev.Type}
+			for _, attr := range ev.Attributes {
+				se.Attributes = append(se.Attributes, types.Attribute{Key: string(attr.Key), Value: string(attr.Value)})
+			}
+
+			sub, err := mapper.BlockEventToSub(se)
+			if err != nil {
+				return nil, fmt.Errorf("error mapping block event %s: %w", ev.Type, err)
+			}
+
+			be := BlockEvent{
+				Height:    block.Height,
+				BlockHash: block.Hash,
+				ChainID:   block.ChainID,
+				Time:      block.Time,
+				Phase:     PhaseBeginBlock,
+				Index:     len(evs),
+				Event:     sub,
+			}
+			if phase == 1 {
+				be.Phase = PhaseEndBlock
+			}
+			evs = append(evs, be)
+		}
+	}
+
+	return evs, nil
+}
+
+// getBlockResults makes block_results call to tendermint rpc. Results are cached with the block,
+// so transactions and block events of the same height are decoded from one call.
+func (c *Client) getBlockResults(ctx context.Context, height uint64) (res apiTypes.ResultBlockResults, err error) {
+	if res, ok := c.Cache.Results(height); ok {
+		return res, nil
+	}
+	if err = c.rpcGet(ctx, "block_results", url.Values{"height": {strconv.FormatUint(height, 10)}}, &res); err != nil {
+		return res, err
+	}
+	c.Cache.AddResults(height, res)
+	return res, nil
+}
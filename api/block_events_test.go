@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlockResults serves tendermint rpc block_results with base64 encoded attributes
+func fakeBlockResults(t *testing.T, begin, end map[string][][2]string) *httptest.Server {
+	t.Helper()
+
+	events := func(evs map[string][][2]string) string {
+		var out []string
+		for _, typ := range []string{"mint", "transfer", "proposer_reward", "slash", "complete_unbonding", "active_proposal"} {
+			attrs, ok := evs[typ]
+			if !ok {
+				continue
+			}
+			var as []string
+			for _, kv := range attrs {
+				as = append(as, fmt.Sprintf(`{"key":"%s","value":"%s","index":true}`,
+					base64.StdEncoding.EncodeToString([]byte(kv[0])), base64.StdEncoding.EncodeToString([]byte(kv[1]))))
+			}
+			out = append(out, fmt.Sprintf(`{"type":"%s","attributes":[%s]}`, typ, strings.Join(as, ",")))
+		}
+		return "[" + strings.Join(out, ",") + "]"
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/block_results" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("height") == "999" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":-1,"error":{"code":-32603,"message":"Internal error","data":"height 999 must be less than or equal to the current blockchain height 10"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"height":"%s","txs_results":null,"begin_block_events":%s,"end_block_events":%s}}`,
+			r.URL.Query().Get("height"), events(begin), events(end))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientGetBlockEvents(t *testing.T) {
+	srv := fakeBlockResults(t, map[string][][2]string{
+		"mint":            {{"bonded_ratio", "0.67"}, {"inflation", "0.07"}, {"annual_provisions", "1000.5"}, {"amount", "2000"}},
+		"transfer":        {{"recipient", "cosmos1distribution"}, {"sender", "cosmos1feecollector"}, {"amount", "2000uatom"}},
+		"proposer_reward": {{"amount", "10.5uatom,3ustake"}, {"validator", "cosmosvaloper1proposer"}},
+		"slash":           {{"address", "cosmosvalcons1slashed"}, {"power", "100"}, {"reason", "missing_signature"}, {"jailed", "cosmosvalcons1slashed"}},
+	}, map[string][][2]string{
+		"complete_unbonding": {{"amount", "500uatom"}, {"validator", "cosmosvaloper1val"}, {"delegator", "cosmos1delegator"}},
+		"active_proposal":    {{"proposal_id", "7"}, {"proposal_result", "proposal_passed"}},
+	})
+
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 10})
+	cli := newTestClient(t, &ClientConfig{TendermintRPCAddr: strings.TrimPrefix(srv.URL, "http://")}, fn)
+
+	block := structs.Block{Height: 5, Hash: "HASH5", ChainID: "test-1"}
+	evs, err := cli.GetBlockEvents(context.Background(), block)
+	require.NoError(t, err)
+	require.Len(t, evs, 6)
+
+	for i, ev := range evs {
+		require.Equal(t, i, ev.Index)
+		require.Equal(t, uint64(5), ev.Height)
+		require.Equal(t, "HASH5", ev.BlockHash)
+	}
+
+	mint := evs[0]
+	require.Equal(t, PhaseBeginBlock, mint.Phase)
+	require.Equal(t, "mint", mint.Event.Module)
+	require.Equal(t, "2000", mint.Event.Amount["mint"].Numeric.String())
+	require.Equal(t, []string{"0.07"}, mint.Event.Additional["inflation"])
+
+	transfer := evs[1]
+	require.Equal(t, "bank", transfer.Event.Module)
+	require.Equal(t, "cosmos1feecollector", transfer.Event.Sender[0].Account.ID)
+	require.Equal(t, "cosmos1distribution", transfer.Event.Recipient[0].Account.ID)
+	require.Equal(t, "uatom", transfer.Event.Recipient[0].Amounts[0].Currency)
+	require.Equal(t, "cosmos1distribution", transfer.Event.Transfers["send"][0].Account.ID)
+
+	reward := evs[2]
+	require.Equal(t, "distribution", reward.Event.Module)
+	require.Equal(t, "cosmosvaloper1proposer", reward.Event.Node["validator"][0].ID)
+	require.Equal(t, "105", reward.Event.Amount["proposer_reward"].Numeric.String())
+	require.Equal(t, int32(1), reward.Event.Amount["proposer_reward"].Exp)
+	// every denom of multi-coin amount is kept
+	require.Equal(t, "ustake", reward.Event.Amount["proposer_reward_1"].Currency)
+	require.Equal(t, "3", reward.Event.Amount["proposer_reward_1"].Numeric.String())
+
+	slash := evs[3]
+	require.Equal(t, "slashing", slash.Event.Module)
+	require.Equal(t, "cosmosvalcons1slashed", slash.Event.Node["validator"][0].ID)
+	require.Equal(t, []string{"missing_signature"}, slash.Event.Additional["reason"])
+
+	unbonding := evs[4]
+	require.Equal(t, PhaseEndBlock, unbonding.Phase)
+	require.Equal(t, "staking", unbonding.Event.Module)
+	require.Equal(t, "cosmos1delegator", unbonding.Event.Node["delegator"][0].ID)
+	require.Equal(t, "500", unbonding.Event.Amount["complete_unbonding"].Numeric.String())
+
+	proposal := evs[5]
+	require.Equal(t, "gov", proposal.Event.Module)
+	require.Equal(t, []string{"proposal_passed"}, proposal.Event.Additional["proposal_result"])
+
+	_, err = cli.GetBlockEvents(context.Background(), structs.Block{Height: 999})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be less than or equal")
+}
+
+func TestClientGetBlockEventsDisabled(t *testing.T) {
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 10})
+	cli := newTestClient(t, &ClientConfig{}, fn)
+
+	evs, err := cli.GetBlockEvents(context.Background(), structs.Block{Height: 5})
+	require.NoError(t, err)
+	require.Empty(t, evs)
+}
+
+func TestClientRPCFailover(t *testing.T) {
+	good := fakeBlockResults(t, map[string][][2]string{"mint": {{"amount", "2000"}}}, nil)
+	// failing endpoint responds with error of the proxy in front of it
+	failing := func(times int32) (*httptest.Server, *int32) {
+		hits := new(int32)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(hits, 1) <= times {
+				w.WriteHeader(http.StatusBadGateway)
+				fmt.Fprint(w, "<html>502 Bad Gateway</html>")
+				return
+			}
+			good.Config.Handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, hits
+	}
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 10})
+	ctx := context.Background()
+
+	t.Run("next endpoint", func(t *testing.T) {
+		down, hits := failing(1000)
+		cli := newTestClient(t, &ClientConfig{TendermintRPCAddr: down.URL + ", " + good.URL}, fn)
+		evs, err := cli.GetBlockEvents(ctx, structs.Block{Height: 5})
+		require.NoError(t, err)
+		require.Len(t, evs, 1)
+		require.Equal(t, int32(1), atomic.LoadInt32(hits))
+	})
+
+	t.Run("retried with backoff", func(t *testing.T) {
+		flaky, hits := failing(2)
+		cli := newTestClient(t, &ClientConfig{TendermintRPCAddr: flaky.URL, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 5 * time.Millisecond}, fn)
+		evs, err := cli.GetBlockEvents(ctx, structs.Block{Height: 5})
+		require.NoError(t, err)
+		require.Len(t, evs, 1)
+		require.Equal(t, int32(3), atomic.LoadInt32(hits))
+	})
+
+	t.Run("request error is not repeated", func(t *testing.T) {
+		other, hits := failing(0)
+		cli := newTestClient(t, &ClientConfig{TendermintRPCAddr: good.URL + "," + other.URL, RetryBaseDelay: time.Millisecond}, fn)
+		_, err := cli.GetBlockEvents(ctx, structs.Block{Height: 999})
+		require.Error(t, err)
+		require.Equal(t, int32(0), atomic.LoadInt32(hits))
+	})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/tendermint/tendermint/libs/bytes"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+)
+
+// BlockHeader is full tendermint header of the block, hashes are hex encoded
+type BlockHeader struct {
+	VersionBlock uint64    `json:"version_block"`
+	VersionApp   uint64    `json:"version_app"`
+	ChainID      string    `json:"chain_id"`
+	Height       uint64    `json:"height"`
+	Time         time.Time `json:"time"`
+
+	LastBlockID BlockID `json:"last_block_id"`
+
+	LastCommitHash     string `json:"last_commit_hash"`
+	DataHash           string `json:"data_hash"`
+	ValidatorsHash     string `json:"validators_hash"`
+	NextValidatorsHash string `json:"next_validators_hash"`
+	ConsensusHash      string `json:"consensus_hash"`
+	AppHash            string `json:"app_hash"`
+	LastResultsHash    string `json:"last_results_hash"`
+	EvidenceHash       string `json:"evidence_hash"`
+	// ProposerAddress consensus address (cosmosvalcons) of block proposer
+	ProposerAddress string `json:"proposer_address"`
+
+	// Size of the whole block in bytes (protobuf encoded)
+	Size uint64 `json:"size"`
+}
+
+// BlockID is hash of the block with header of its parts
+type BlockID struct {
+	Hash         string `json:"hash"`
+	PartSetTotal uint32 `json:"part_set_total"`
+	PartSetHash  string `json:"part_set_hash"`
+}
+
+// BlockTotals are aggregates of all transactions in block
+type BlockTotals struct {
+	// Fees sum of fees per currency
+	Fees      []structs.TransactionAmount `json:"fees"`
+	GasWanted uint64                      `json:"gas_wanted"`
+	GasUsed   uint64                      `json:"gas_used"`
+}
+
+// ExtendedBlock is block with its full header and aggregates of its transactions
+type ExtendedBlock struct {
+	structs.Block
+	Header BlockHeader `json:"header"`
+	Totals BlockTotals `json:"totals"`
+}
+
+// NewExtendedBlock is ExtendedBlock constructor, txs should be all the transactions of the block
+func NewExtendedBlock(block structs.Block, header BlockHeader, txs []structs.Transaction) ExtendedBlock {
+	return ExtendedBlock{Block: block, Header: header, Totals: blockTotals(txs)}
+}
+
+// GetBlockHeader gets full header of block of given height
+func (c *Client) GetBlockHeader(ctx context.Context, height uint64) (header BlockHeader, err error) {
+	var ok bool
+	if header, ok = c.Cache.Header(height); ok {
+		return header, nil
+	}
+	if header, ok = c.DiskCache.Header(height); ok {
+		c.Cache.AddHeader(height, header)
+		return header, nil
+	}
+
+	_, header, _, err = c.fetchBlock(ctx, height)
+	return header, err
+}
+
+func blockHeader(bl *tmproto.Block) BlockHeader {
+	h := bl.Header
+	return BlockHeader{
+		VersionBlock: h.Version.Block,
+		VersionApp:   h.Version.App,
+		ChainID:      h.ChainID,
+		Height:       uint64(h.Height),
+		Time:         h.Time,
+		LastBlockID: BlockID{
+			Hash:         bytes.HexBytes(h.LastBlockId.Hash).String(),
+			PartSetTotal: h.LastBlockId.PartSetHeader.Total,
+			PartSetHash:  bytes.HexBytes(h.LastBlockId.PartSetHeader.Hash).String(),
+		},
+		LastCommitHash:     bytes.HexBytes(h.LastCommitHash).String(),
+		DataHash:           bytes.HexBytes(h.DataHash).String(),
+		ValidatorsHash:     bytes.HexBytes(h.ValidatorsHash).String(),
+		NextValidatorsHash: bytes.HexBytes(h.NextValidatorsHash).String(),
+		ConsensusHash:      bytes.HexBytes(h.ConsensusHash).String(),
+		AppHash:            bytes.HexBytes(h.AppHash).String(),
+		LastResultsHash:    bytes.HexBytes(h.LastResultsHash).String(),
+		EvidenceHash:       bytes.HexBytes(h.EvidenceHash).String(),
+		ProposerAddress:    sdk.ConsAddress(h.ProposerAddress).String(),
+		Size:               uint64(bl.Size()),
+	}
+}
+
+// blockTotals sums gas and fees (per currency and exponent) of transactions
+func blockTotals(txs []structs.Transaction) (bt BlockTotals) {
+	type feeKey struct {
+		currency string
+		exp      int32
+	}
+	fees := map[feeKey]int{}
+
+	for _, t := range txs {
+		bt.GasWanted += t.GasWanted
+		bt.GasUsed += t.GasUsed
+		for _, f := range t.Fee {
+			if f.Numeric == nil {
+				continue
+			}
+			k := feeKey{f.Currency, f.Exp}
+			i, ok := fees[k]
+			if !ok {
+				i = len(bt.Fees)
+				fees[k] = i
+				bt.Fees = append(bt.Fees, structs.TransactionAmount{Currency: f.Currency, Exp: f.Exp, Numeric: new(big.Int)})
+			}
+			bt.Fees[i].Numeric.Add(bt.Fees[i].Numeric, f.Numeric)
+		}
+	}
+
+	for i, f := range bt.Fees {
+		bt.Fees[i].Text = f.Numeric.String()
+	}
+	return bt
+}
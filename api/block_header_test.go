@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+func TestClientGetBlockHeader(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, txsPerBlock: 2, validators: 2}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	header, err := cli.GetBlockHeader(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), header.Height)
+	require.Equal(t, "test-1", header.ChainID)
+	require.Equal(t, bytes.HexBytes("app-10").String(), header.AppHash)
+	require.Equal(t, bytes.HexBytes("block-9").String(), header.LastBlockID.Hash)
+	require.Equal(t, chain.validatorConsAddress(0), header.ProposerAddress)
+	require.NotZero(t, header.Size)
+
+	// block and header are fetched with one call
+	served := fn.served()
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 10})
+	require.NoError(t, err)
+	require.Equal(t, served, fn.served())
+	require.Equal(t, header.Time, block.Time)
+
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 11})
+	require.NoError(t, err)
+	served = fn.served()
+	header, err = cli.GetBlockHeader(ctx, 11)
+	require.NoError(t, err)
+	require.Equal(t, served, fn.served())
+	require.Equal(t, bytes.HexBytes("block-10").String(), header.LastBlockID.Hash)
+}
+
+func TestBlockTotals(t *testing.T) {
+	fee := func(amount int64, currency string) structs.TransactionAmount {
+		return structs.TransactionAmount{Text: big.NewInt(amount).String(), Numeric: big.NewInt(amount), Currency: currency}
+	}
+	txs := []structs.Transaction{
+		{GasWanted: 200, GasUsed: 150, Fee: []structs.TransactionAmount{fee(10, "uatom")}},
+		{GasWanted: 100, GasUsed: 100, Fee: []structs.TransactionAmount{fee(5, "uatom"), fee(1, "uosmo")}},
+		{GasWanted: 50, GasUsed: 60},
+	}
+
+	eb := NewExtendedBlock(structs.Block{Height: 5}, BlockHeader{Height: 5}, txs)
+	require.Equal(t, uint64(350), eb.Totals.GasWanted)
+	require.Equal(t, uint64(310), eb.Totals.GasUsed)
+	require.Equal(t, []structs.TransactionAmount{fee(15, "uatom"), fee(1, "uosmo")}, eb.Totals.Fees)
+
+	// fees of transactions are not modified
+	require.Equal(t, fee(10, "uatom"), txs[0].Fee[0])
+
+	require.Empty(t, NewExtendedBlock(structs.Block{}, BlockHeader{}, nil).Totals.Fees)
+}
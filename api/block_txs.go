@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	codec_types "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
+)
+
+// Sources of transactions
+const (
+	// TxSourceTxsEvent searches transactions with GetTxsEvent, requires tx indexer enabled on node
+	TxSourceTxsEvent = "txs_event"
+	// TxSourceBlock decodes transactions from block data pairing them with results from tendermint rpc block_results,
+	// falls back to GetTxsEvent when it's not possible
+	TxSourceBlock = "block"
+)
+
+// txsFromBlock decodes transactions from data of the block of given height, in the same format GetTxsEvent returns them
+func (c *Client) txsFromBlock(ctx context.Context, height uint64) (res *tx.GetTxsEventResponse, err error) {
+	raw, ok := c.Cache.Txs(height)
+	if !ok {
+		if _, _, _, err = c.fetchBlock(ctx, height); err != nil {
+			return nil, err
+		}
+		if raw, ok = c.Cache.Txs(height); !ok {
+			return nil, fmt.Errorf("block data of height %d is not available", height)
+		}
+	}
+
+	var results []apiTypes.ResponseTx
+	if len(raw) > 0 {
+		br, err := c.getBlockResults(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		results = br.TxsResults
+	}
+	if len(results) != len(raw) {
+		return nil, fmt.Errorf("block has %d transactions and %d results", len(raw), len(results))
+	}
+
+	res = &tx.GetTxsEventResponse{}
+	for i, rtx := range raw {
+		t, err := decodeTx(rtx)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding transaction %d: %w", i, err)
+		}
+
+		r := results[i]
+		logs, _ := types.ParseABCILogs(r.Log) // log of failed transaction is not json
+		res.Txs = append(res.Txs, t)
+		res.TxResponses = append(res.TxResponses, &types.TxResponse{
+			Height:    int64(height),
+			TxHash:    txHash(rtx),
+			Codespace: r.Codespace,
+			Code:      r.Code,
+			Data:      strings.ToUpper(hex.EncodeToString(r.Data)),
+			RawLog:    r.Log,
+			Logs:      logs,
+			Info:      r.Info,
+			GasWanted: r.GasWanted,
+			GasUsed:   r.GasUsed,
+			Tx:        &codec_types.Any{TypeUrl: "/cosmos.tx.v1beta1.Tx", Value: rtx},
+		})
+	}
+	return res, nil
+}
+
+// decodeTx decodes protobuf encoded transaction (TxRaw), messages are left packed
+func decodeTx(raw []byte) (*tx.Tx, error) {
+	var txr tx.TxRaw
+	if err := txr.Unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	t := &tx.Tx{Body: &tx.TxBody{}, AuthInfo: &tx.AuthInfo{}, Signatures: txr.Signatures}
+	if err := t.Body.Unmarshal(txr.BodyBytes); err != nil {
+		return nil, fmt.Errorf("error decoding body: %w", err)
+	}
+	if err := t.AuthInfo.Unmarshal(txr.AuthInfoBytes); err != nil {
+		return nil, fmt.Errorf("error decoding auth info: %w", err)
+	}
+	return t, nil
+}
+
+// txHash is hash of the transaction, as tendermint computes it
+func txHash(raw []byte) string {
+	h := sha256.Sum256(raw)
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTxsResults serves tendermint rpc block_results with results of transactions of the chain,
+// missing makes it return results of one transaction less
+func fakeTxsResults(t testing.TB, chain *fakeChain, missing *int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		height, _ := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+
+		results := []map[string]interface{}{}
+		for i := 0; i < chain.txsPerBlock; i++ {
+			_, resp := chain.tx(height, i)
+			results = append(results, map[string]interface{}{
+				"code":       0,
+				"log":        resp.RawLog,
+				"gas_wanted": strconv.FormatInt(resp.GasWanted, 10),
+				"gas_used":   strconv.FormatInt(resp.GasUsed, 10),
+				"events":     []interface{}{},
+			})
+		}
+		if missing != nil && atomic.LoadInt32(missing) > 0 {
+			results = results[1:]
+		}
+
+		res, _ := json.Marshal(results)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"height":"%d","txs_results":%s,"begin_block_events":null,"end_block_events":null}}`, height, res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientSearchTxFromBlock(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, txsPerBlock: 3}
+	fn := startFakeNode(t, chain)
+	var missing int32
+	srv := fakeTxsResults(t, chain, &missing)
+	ctx := context.Background()
+
+	indexed := newTestClient(t, &ClientConfig{}, fn)
+	decoded := newTestClient(t, &ClientConfig{TxSource: TxSourceBlock, TendermintRPCAddr: strings.TrimPrefix(srv.URL, "http://")}, fn)
+
+	block, err := decoded.GetBlock(ctx, structs.HeightHash{Height: 10})
+	require.NoError(t, err)
+
+	served := fn.served()
+	txs, err := decoded.SearchTx(ctx, structs.HeightHash{Height: 10}, block, 100)
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	require.Equal(t, served, fn.served(), "transactions decoded from cached block")
+
+	expected, err := indexed.SearchTx(ctx, structs.HeightHash{Height: 10}, block, 100)
+	require.NoError(t, err)
+	require.Equal(t, expected, txs)
+	require.Equal(t, "tx-10-1", txs[1].Memo)
+	require.Equal(t, uint64(100001), txs[1].GasUsed)
+
+	t.Run("block not cached", func(t *testing.T) {
+		block, err := indexed.GetBlock(ctx, structs.HeightHash{Height: 11})
+		require.NoError(t, err)
+
+		txs, err := decoded.SearchTx(ctx, structs.HeightHash{Height: 11}, block, 100)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+		require.Equal(t, "tx-11-0", txs[0].Memo)
+	})
+
+	t.Run("block results shared with block events", func(t *testing.T) {
+		var calls int32
+		counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			srv.Config.Handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(counted.Close)
+		cli := newTestClient(t, &ClientConfig{TxSource: TxSourceBlock, TendermintRPCAddr: counted.URL}, fn)
+
+		block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 14})
+		require.NoError(t, err)
+		_, err = cli.SearchTx(ctx, structs.HeightHash{Height: 14}, block, 100)
+		require.NoError(t, err)
+		_, err = cli.GetBlockEvents(ctx, block)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("fallback when results don't match", func(t *testing.T) {
+		atomic.StoreInt32(&missing, 1)
+		defer atomic.StoreInt32(&missing, 0)
+
+		block, err := decoded.GetBlock(ctx, structs.HeightHash{Height: 12})
+		require.NoError(t, err)
+
+		served := fn.served()
+		txs, err := decoded.SearchTx(ctx, structs.HeightHash{Height: 12}, block, 100)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+		require.Equal(t, served+1, fn.served(), "transactions fetched with GetTxsEvent")
+	})
+
+	t.Run("fallback without tendermint rpc", func(t *testing.T) {
+		cli := newTestClient(t, &ClientConfig{TxSource: TxSourceBlock}, fn)
+		txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 13}, structs.Block{Height: 13}, 100)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+	})
+}
+
+func TestDecodeTx(t *testing.T) {
+	chain := &fakeChain{}
+	expected, resp := chain.tx(5, 1)
+
+	raw := chain.rawTx(expected)
+	decoded, err := decodeTx(raw)
+	require.NoError(t, err)
+	expectedBytes, err := expected.Marshal()
+	require.NoError(t, err)
+	decodedBytes, err := decoded.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, expectedBytes, decodedBytes)
+	require.Equal(t, resp.TxHash, txHash(raw))
+
+	_, err = decodeTx([]byte("not a transaction"))
+	require.Error(t, err)
+}
+
+func BenchmarkSearchTx(b *testing.B) {
+	chain := &fakeChain{chainID: "test-1", height: 1000, txsPerBlock: 200}
+	fn := startFakeNode(b, chain)
+	srv := fakeTxsResults(b, chain, nil)
+
+	for _, source := range []string{TxSourceTxsEvent, TxSourceBlock} {
+		b.Run(source, func(b *testing.B) {
+			cli := newTestClient(b, &ClientConfig{TxSource: source, TendermintRPCAddr: strings.TrimPrefix(srv.URL, "http://"), BlockCacheSize: 1}, fn)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				height := uint64(i%int(chain.height)) + 1
+				block, err := cli.GetBlock(ctx, structs.HeightHash{Height: height})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := cli.SearchTx(ctx, structs.HeightHash{Height: height}, block, 100); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBreakerThreshold   = 5
+	defaultBreakerOpenTimeout = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned for every call while cosmos nodes are considered down
+var ErrCircuitOpen = errors.New("circuit breaker is open, cosmos node is unavailable")
+
+// BreakerState state of the circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (bs BreakerState) String() string {
+	switch bs {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker stops calls to the nodes after sustained failures.
+// After openTimeout a single probe is let through (half-open), which closes the breaker on success.
+type circuitBreaker struct {
+	l           sync.Mutex
+	state       BreakerState
+	failures    int
+	threshold   int
+	openTimeout time.Duration
+	openedAt    time.Time
+}
+
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if openTimeout <= 0 {
+		openTimeout = defaultBreakerOpenTimeout
+	}
+	return &circuitBreaker{threshold: threshold, openTimeout: openTimeout}
+}
+
+// State returns current state
+func (cb *circuitBreaker) State() BreakerState {
+	cb.l.Lock()
+	defer cb.l.Unlock()
+	return cb.state
+}
+
+// allow checks if calls are allowed. When open timeout has passed
+// the first caller gets probe set to true and has to report the result.
+func (cb *circuitBreaker) allow() (allowed, probe bool) {
+	cb.l.Lock()
+	defer cb.l.Unlock()
+
+	switch cb.state {
+	case BreakerClosed:
+		return true, false
+	case BreakerOpen:
+		if time.Since(cb.openedAt) < cb.openTimeout {
+			return false, false
+		}
+		cb.setState(BreakerHalfOpen)
+		return false, true
+	}
+	// half-open, somebody else is probing
+	return false, false
+}
+
+// success records successful call
+func (cb *circuitBreaker) success() {
+	cb.l.Lock()
+	defer cb.l.Unlock()
+
+	cb.failures = 0
+	if cb.state != BreakerClosed {
+		cb.setState(BreakerClosed)
+	}
+}
+
+// failure records failed call, opening the breaker after threshold failures in a row
+func (cb *circuitBreaker) failure() (opened bool) {
+	cb.l.Lock()
+	defer cb.l.Unlock()
+
+	cb.failures++
+	if cb.state == BreakerHalfOpen || (cb.state == BreakerClosed && cb.failures >= cb.threshold) {
+		cb.openedAt = time.Now()
+		cb.setState(BreakerOpen)
+		return true
+	}
+	return false
+}
+
+func (cb *circuitBreaker) setState(state BreakerState) {
+	cb.state = state
+	breakerState.WithLabels().Set(float64(state))
+}
+
+// BreakerState returns state of the circuit breaker
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// recordBreaker records result of the call in circuit breaker.
+// Only failures of the nodes count, errors caused by request mean that node is working.
+func (c *Client) recordBreaker(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if err == nil || !isNodeError(err) {
+		c.breaker.success()
+		return
+	}
+	if _, pruned := prunedHeight(err); pruned {
+		return
+	}
+	if c.breaker.failure() {
+		c.logger.Warn("[COSMOS-API] Circuit breaker opened", zap.Error(err))
+	}
+}
+
+// checkBreaker returns ErrCircuitOpen if calls are not allowed,
+// probing the nodes with GetLatestBlock when it's time to do so.
+func (c *Client) checkBreaker() error {
+	allowed, probe := c.breaker.allow()
+	if allowed {
+		return nil
+	}
+	if !probe {
+		breakerRejections.WithLabels().Inc()
+		return ErrCircuitOpen
+	}
+
+	// probe doesn't depend on the caller, its cancellation says nothing about the nodes
+	pctx, cancel := context.WithTimeout(context.Background(), c.cfg.TimeoutBlockCall)
+	defer cancel()
+	err := c.callNodes(pctx, "GetLatestBlock", 0, func(n *node) error {
+		lb, err := n.tmServiceClient.GetLatestBlock(pctx, &tmservice.GetLatestBlockRequest{})
+		if err == nil {
+			n.setLatestHeight(uint64(lb.Block.Header.Height))
+		}
+		return err
+	})
+	if err != nil {
+		c.logger.Warn("[COSMOS-API] Circuit breaker probe failed", zap.Error(err))
+		c.breaker.failure()
+		breakerRejections.WithLabels().Inc()
+		return ErrCircuitOpen
+	}
+
+	c.logger.Info("[COSMOS-API] Circuit breaker closed")
+	c.breaker.success()
+	return nil
+}
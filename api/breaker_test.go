@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientCircuitBreaker(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{MaxRetries: -1, BreakerThreshold: 2, BreakerOpenTimeout: 50 * time.Millisecond}, fn)
+	ctx := context.Background()
+
+	fn.setFailing(codes.Unavailable)
+	for i := 0; i < 2; i++ {
+		_, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+	require.Equal(t, BreakerOpen, cli.BreakerState())
+	require.Error(t, NewBreakerProber(cli).Probe(ctx))
+
+	// fail fast without calling the node
+	served := fn.served()
+	_, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.True(t, errors.Is(err, ErrCircuitOpen))
+	require.Equal(t, served, fn.served())
+
+	// half-open probe fails, breaker opens again
+	time.Sleep(60 * time.Millisecond)
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.True(t, errors.Is(err, ErrCircuitOpen))
+	require.Equal(t, served+1, fn.served())
+	require.Equal(t, BreakerOpen, cli.BreakerState())
+
+	// half-open probe succeeds, breaker closes
+	fn.setFailing(codes.OK)
+	time.Sleep(60 * time.Millisecond)
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), block.Height)
+	require.Equal(t, BreakerClosed, cli.BreakerState())
+	require.NoError(t, NewBreakerProber(cli).Probe(ctx))
+}
+
+func TestClientCircuitBreakerProbeOfCancelledCall(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{MaxRetries: -1, BreakerThreshold: 1, BreakerOpenTimeout: 20 * time.Millisecond}, fn)
+
+	fn.failNext(codes.Unavailable, 1)
+	_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+	require.Error(t, err)
+	require.Equal(t, BreakerOpen, cli.BreakerState())
+
+	// caller is gone, the probe still finds the node working
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, BreakerClosed, cli.BreakerState())
+}
+
+func TestClientCircuitBreakerIgnoresRequestErrors(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{BreakerThreshold: 2}, fn)
+
+	for i := 0; i < 5; i++ {
+		_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 100})
+		require.Error(t, err)
+	}
+	require.Equal(t, BreakerClosed, cli.BreakerState())
+}
+
+func TestClientUnknownErrorIsRequestError(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	nodes := []*fakeNode{startFakeNode(t, chain), startFakeNode(t, chain)}
+	cli := newTestClient(t, &ClientConfig{BreakerThreshold: 2, EndpointMaxFailures: 1}, nodes...)
+	for _, fn := range nodes {
+		fn.setFailing(codes.Unknown)
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+		require.Equal(t, codes.Unknown, status.Code(err))
+	}
+	// neither retried nor failed over
+	require.Equal(t, int64(5), nodes[0].served()+nodes[1].served())
+	require.Equal(t, BreakerClosed, cli.BreakerState())
+	for _, st := range cli.Endpoints() {
+		require.True(t, st.Healthy)
+	}
+}
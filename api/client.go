@@ -1,55 +1,112 @@
 package api
 
 import (
+	"net/http"
 	"time"
 
-	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
-	"github.com/cosmos/cosmos-sdk/types/tx"
-	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
-	distributionTypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
-	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 )
 
 type ClientConfig struct {
+	// ReqPerSecond maximum number of requests per second to every endpoint
 	ReqPerSecond        int
 	TimeoutBlockCall    time.Duration
 	TimeoutSearchTxCall time.Duration
+
+	// EndpointMaxFailures number of failures in a row after which endpoint is taken out of the pool
+	EndpointMaxFailures int
+	// EndpointCooldown time after which evicted endpoint is tried again
+	EndpointCooldown time.Duration
+
+	// MaxRetries number of retries of transient failures (0 - default, negative - none)
+	MaxRetries int
+	// RetryBaseDelay delay before the first retry, doubled with every next one
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay maximum delay between retries
+	RetryMaxDelay time.Duration
+
+	// PriorityReserve share of ReqPerSecond of every node reserved for high priority calls (0 - default, negative - none)
+	PriorityReserve float64
+
+	// BreakerThreshold number of failed calls in a row that opens the circuit breaker
+	BreakerThreshold int
+	// BreakerOpenTimeout time after which open circuit breaker probes the nodes again
+	BreakerOpenTimeout time.Duration
+
+	// AuthToken is sent with every call as bearer token
+	AuthToken string
+	// APIKey is sent with every call in APIKeyHeader (x-api-key by default)
+	APIKey       string
+	APIKeyHeader string
+
+	// BlockCacheSize number of blocks kept in cache
+	BlockCacheSize int
+	// BlockCacheTipTTL time the latest block is served from cache (negative - not cached)
+	BlockCacheTipTTL time.Duration
+	// AccountCacheSize number of account query results at past heights kept in cache (negative - disabled)
+	AccountCacheSize int
+
+	// MissedBlocks counts blocks every validator missed in signed blocks window of slashing module
+	MissedBlocks bool
+
+	// TendermintRPCAddr address of tendermint rpc (comma separated list, tried in order),
+	// used for data not available over grpc (block events, transaction results)
+	TendermintRPCAddr string
+	// TxPageConcurrency number of pages of transactions of one block fetched at the same time
+	TxPageConcurrency int
+	// TxSource is where transactions are taken from, TxSourceTxsEvent (default) or TxSourceBlock
+	TxSource string
 }
 
 // Client
 type Client struct {
 	logger *zap.Logger
-	cli    *grpc.ClientConn
-	Sbc    *SimpleBlockCache
+	Cache  *BlockCache
+	// DiskCache optional persistent cache of blocks and transactions (nil - disabled)
+	DiskCache *DiskCache
 
 	// GRPC
-	txServiceClient    tx.ServiceClient
-	tmServiceClient    tmservice.ServiceClient
-	rateLimiterGRPC    *rate.Limiter
-	bankClient         bankTypes.QueryClient
-	distributionClient distributionTypes.QueryClient
-	stakingClient      stakingTypes.QueryClient
+	pool      *nodePool
+	accounts  *accountCache
+	signers   *signerSet
+	operators *operatorSet
+	retry     retryPolicy
+	breaker   *circuitBreaker
+
+	// Tendermint RPC
+	httpClient *http.Client
+	rpcAddrs   []string
+	rpcLimiter *priorityLimiter
 
 	cfg *ClientConfig
 }
 
-// NewClient returns a new client for a given endpoint
-func NewClient(logger *zap.Logger, cli *grpc.ClientConn, cfg *ClientConfig) *Client {
-	rateLimiterGRPC := rate.NewLimiter(rate.Limit(cfg.ReqPerSecond), cfg.ReqPerSecond)
+// NewClient returns a new client for a given set of endpoints
+func NewClient(logger *zap.Logger, conns []*grpc.ClientConn, cfg *ClientConfig) *Client {
+	var opts []grpc.CallOption
+	if creds := newCallCredentials(cfg); len(creds) > 0 {
+		opts = append(opts, grpc.PerRPCCredentials(creds))
+	}
+
+	if cfg.TxSource == TxSourceBlock && cfg.TendermintRPCAddr == "" {
+		logger.Warn("[COSMOS-API] Transactions can't be decoded from blocks without tendermint rpc address, GetTxsEvent is used instead")
+	}
 
 	return &Client{
-		logger:             logger,
-		Sbc:                NewSimpleBlockCache(400),
-		tmServiceClient:    tmservice.NewServiceClient(cli),
-		txServiceClient:    tx.NewServiceClient(cli),
-		bankClient:         bankTypes.NewQueryClient(cli),
-		distributionClient: distributionTypes.NewQueryClient(cli),
-		stakingClient:      stakingTypes.NewQueryClient(cli),
-		rateLimiterGRPC:    rateLimiterGRPC,
-		cfg:                cfg,
+		logger:    logger,
+		Cache:     NewBlockCache(cfg.BlockCacheSize, cfg.BlockCacheTipTTL),
+		pool:      newNodePool(conns, opts, cfg),
+		accounts:  newAccountCache(cfg.AccountCacheSize),
+		signers:   &signerSet{},
+		operators: &operatorSet{},
+		retry:     newRetryPolicy(cfg),
+		breaker:   newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerOpenTimeout),
+		cfg:       cfg,
+
+		httpClient: &http.Client{},
+		rpcAddrs:   rpcAddresses(cfg.TendermintRPCAddr),
+		rpcLimiter: newPriorityLimiter(float64(cfg.ReqPerSecond), cfg.PriorityReserve),
 	}
 }
 
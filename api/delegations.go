@@ -15,10 +15,18 @@ import (
 
 // GetAccountDelegations fetches account delegations
 func (c *Client) GetAccountDelegations(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountDelegationsResponse, err error) {
+	if cached, ok := c.cachedAccount("delegations", params); ok {
+		return cached.(structs.GetAccountDelegationsResponse), nil
+	}
+
 	resp.Height = params.Height
 
-	delResp, err := c.stakingClient.DelegatorDelegations(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
-		&types.QueryDelegatorDelegationsRequest{DelegatorAddr: params.Account})
+	var delResp *types.QueryDelegatorDelegationsResponse
+	err = c.call(ctx, "DelegatorDelegations", params.Height, func(n *node) (err error) {
+		delResp, err = n.stakingClient.DelegatorDelegations(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
+			&types.QueryDelegatorDelegationsRequest{DelegatorAddr: params.Account})
+		return err
+	})
 	if err != nil {
 		return resp, fmt.Errorf("[COSMOS-API] Error fetching delegations: %w", err)
 	}
@@ -41,5 +49,6 @@ func (c *Client) GetAccountDelegations(ctx context.Context, params structs.Heigh
 		)
 	}
 
-	return resp, err
+	c.cacheAccount("delegations", params, resp)
+	return resp, nil
 }
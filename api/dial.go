@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const defaultAPIKeyHeader = "x-api-key"
+
+// TLSConfig configures transport security of connections to cosmos nodes
+type TLSConfig struct {
+	Enabled bool
+	// CAFile PEM bundle of certificate authorities to verify the node with (system pool by default)
+	CAFile string
+	// CertFile and KeyFile PEM client certificate and its key (mutual TLS)
+	CertFile string
+	KeyFile  string
+	// ServerName overrides name the node certificate is verified against
+	ServerName string
+}
+
+// DialOption returns transport credentials option
+func (tc TLSConfig) DialOption() (grpc.DialOption, error) {
+	if !tc.Enabled {
+		return grpc.WithInsecure(), nil
+	}
+
+	conf := &tls.Config{
+		ServerName: tc.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if tc.CAFile != "" {
+		pem, err := ioutil.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca file: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in ca file")
+		}
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(conf)), nil
+}
+
+// Dial connects to cosmos node
+func Dial(ctx context.Context, addr string, tc TLSConfig) (*grpc.ClientConn, error) {
+	opt, err := tc.DialOption()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.DialContext(ctx, addr, opt)
+}
+
+// callCredentials are credentials attached to every call
+type callCredentials map[string]string
+
+func newCallCredentials(cfg *ClientConfig) callCredentials {
+	cc := callCredentials{}
+	if cfg.AuthToken != "" {
+		cc["authorization"] = "Bearer " + cfg.AuthToken
+	}
+	if cfg.APIKey != "" {
+		header := cfg.APIKeyHeader
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		cc[header] = cfg.APIKey
+	}
+	return cc
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (cc callCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return cc, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials,
+// secrets are never sent over plain connection
+func (cc callCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// callOptionsConn adds call options to every call made on connection
+type callOptionsConn struct {
+	*grpc.ClientConn
+	opts []grpc.CallOption
+}
+
+func (coc callOptionsConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return coc.ClientConn.Invoke(ctx, method, args, reply, coc.with(opts)...)
+}
+
+func (coc callOptionsConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return coc.ClientConn.NewStream(ctx, desc, method, coc.with(opts)...)
+}
+
+func (coc callOptionsConn) with(opts []grpc.CallOption) []grpc.CallOption {
+	all := make([]grpc.CallOption, 0, len(coc.opts)+len(opts))
+	return append(append(all, coc.opts...), opts...)
+}
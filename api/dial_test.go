@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert creates certificate signed by parent (self-signed CA when parent is nil)
+func newTestCert(t *testing.T, dir, name string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return tc
+}
+
+// authInterceptor accepts only calls with given metadata
+func authInterceptor(header, value string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get(header); len(v) == 0 || v[0] != value {
+			return nil, status.Error(codes.Unauthenticated, "missing credentials")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func TestClientTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "test-ca", nil)
+	server := newTestCert(t, dir, "cosmos.test", ca)
+	client := newTestCert(t, dir, "worker.test", ca)
+	otherCA := newTestCert(t, dir, "other-ca", nil)
+
+	serverCert, err := tls.LoadX509KeyPair(server.certFile, server.keyFile)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain,
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})),
+		grpc.ChainUnaryInterceptor(authInterceptor("authorization", "Bearer secret-token"), authInterceptor("x-api-key", "secret-key")),
+	)
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		cfg     ClientConfig
+		wantErr bool
+	}{
+		{
+			name: "mutual tls with token and api key",
+			tls:  TLSConfig{Enabled: true, CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile, ServerName: "cosmos.test"},
+			cfg:  ClientConfig{AuthToken: "secret-token", APIKey: "secret-key"},
+		},
+		{
+			name:    "missing token",
+			tls:     TLSConfig{Enabled: true, CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile, ServerName: "cosmos.test"},
+			cfg:     ClientConfig{APIKey: "secret-key"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown certificate authority",
+			tls:     TLSConfig{Enabled: true, CAFile: otherCA.certFile, CertFile: client.certFile, KeyFile: client.keyFile, ServerName: "cosmos.test"},
+			cfg:     ClientConfig{AuthToken: "secret-token", APIKey: "secret-key"},
+			wantErr: true,
+		},
+		{
+			name:    "no client certificate",
+			tls:     TLSConfig{Enabled: true, CAFile: ca.certFile, ServerName: "cosmos.test"},
+			cfg:     ClientConfig{AuthToken: "secret-token", APIKey: "secret-key"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			InitMetrics()
+			ctx := context.Background()
+
+			conn, err := Dial(ctx, fn.addr, tt.tls)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			cfg := tt.cfg
+			cfg.ReqPerSecond = 100
+			cfg.TimeoutBlockCall = time.Second
+			cfg.MaxRetries = -1
+			cli := NewClient(zaptest.NewLogger(t), []*grpc.ClientConn{conn}, &cfg)
+
+			block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), block.Height)
+		})
+	}
+}
+
+func TestClientCredentialsRequireTLS(t *testing.T) {
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 10})
+	cli := newTestClient(t, &ClientConfig{AuthToken: "secret-token", MaxRetries: -1}, fn)
+
+	_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, int64(0), fn.served())
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPem := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(notPem, []byte("not a certificate"), 0600))
+
+	_, err := TLSConfig{Enabled: true, CAFile: notPem}.DialOption()
+	require.Error(t, err)
+	_, err = TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.crt")}.DialOption()
+	require.Error(t, err)
+	_, err = TLSConfig{Enabled: true, CertFile: notPem}.DialOption()
+	require.Error(t, err)
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	defaultDiskCacheMaxSize = 1 << 30
+
+	keyBlock      byte = 'b'
+	keyTxs        byte = 't'
+	keySignatures byte = 's'
+	keyHeader     byte = 'h'
+)
+
+// DiskCache persistent cache of finalized blocks and raw transactions (GetTxsEvent responses) by height.
+// Keys start with the height so when cache grows over maxSize the lowest heights are removed first.
+// All methods are safe to call on nil cache (disabled).
+type DiskCache struct {
+	db *leveldb.DB
+
+	l       sync.Mutex
+	size    int64
+	maxSize int64
+}
+
+// OpenDiskCache opens (or creates) cache in given directory
+func OpenDiskCache(dir string, maxSize int64) (*DiskCache, error) {
+	if maxSize <= 0 {
+		maxSize = defaultDiskCacheMaxSize
+	}
+
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error opening disk cache: %w", err)
+	}
+
+	dc := &DiskCache{db: db, maxSize: maxSize}
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		dc.size += int64(len(iter.Key()) + len(iter.Value()))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error reading disk cache: %w", err)
+	}
+	diskCacheSize.WithLabels().Set(float64(dc.size))
+
+	return dc, nil
+}
+
+// Close closes the cache
+func (dc *DiskCache) Close() error {
+	if dc == nil {
+		return nil
+	}
+	return dc.db.Close()
+}
+
+// Size returns approximate size of cached data in bytes
+func (dc *DiskCache) Size() int64 {
+	if dc == nil {
+		return 0
+	}
+	dc.l.Lock()
+	defer dc.l.Unlock()
+	return dc.size
+}
+
+// Block returns cached block of given height
+func (dc *DiskCache) Block(height uint64) (bl structs.Block, ok bool) {
+	v, ok := dc.get(keyBlock, height)
+	if !ok {
+		return bl, false
+	}
+	if err := json.Unmarshal(v, &bl); err != nil {
+		return bl, false
+	}
+	return bl, true
+}
+
+// PutBlock caches block
+func (dc *DiskCache) PutBlock(bl structs.Block) error {
+	if dc == nil {
+		return nil
+	}
+	v, err := json.Marshal(bl)
+	if err != nil {
+		return err
+	}
+	return dc.put(keyBlock, bl.Height, v)
+}
+
+// Signatures returns cached signatures of block of given height
+func (dc *DiskCache) Signatures(height uint64) (sigs BlockSignatures, ok bool) {
+	v, ok := dc.get(keySignatures, height)
+	if !ok {
+		return sigs, false
+	}
+	if err := json.Unmarshal(v, &sigs); err != nil {
+		return sigs, false
+	}
+	return sigs, true
+}
+
+// PutSignatures caches signatures of block of given height
+func (dc *DiskCache) PutSignatures(height uint64, sigs BlockSignatures) error {
+	if dc == nil {
+		return nil
+	}
+	v, err := json.Marshal(sigs)
+	if err != nil {
+		return err
+	}
+	return dc.put(keySignatures, height, v)
+}
+
+// Header returns cached header of block of given height
+func (dc *DiskCache) Header(height uint64) (header BlockHeader, ok bool) {
+	v, ok := dc.get(keyHeader, height)
+	if !ok {
+		return header, false
+	}
+	if err := json.Unmarshal(v, &header); err != nil {
+		return header, false
+	}
+	return header, true
+}
+
+// PutHeader caches block header
+func (dc *DiskCache) PutHeader(header BlockHeader) error {
+	if dc == nil {
+		return nil
+	}
+	v, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return dc.put(keyHeader, header.Height, v)
+}
+
+// TxsEvent returns cached transactions of given height (all pages merged)
+func (dc *DiskCache) TxsEvent(height uint64) (res *tx.GetTxsEventResponse, ok bool) {
+	v, ok := dc.get(keyTxs, height)
+	if !ok {
+		return nil, false
+	}
+	res = &tx.GetTxsEventResponse{}
+	if err := res.Unmarshal(v); err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// PutTxsEvent caches transactions of given height
+func (dc *DiskCache) PutTxsEvent(height uint64, res *tx.GetTxsEventResponse) error {
+	if dc == nil {
+		return nil
+	}
+	v, err := res.Marshal()
+	if err != nil {
+		return err
+	}
+	return dc.put(keyTxs, height, v)
+}
+
+// Invalidate removes everything cached for heights from start to end (inclusive)
+func (dc *DiskCache) Invalidate(start, end uint64) error {
+	if dc == nil {
+		return nil
+	}
+	if end < start {
+		return errors.New("end height is lower than start height")
+	}
+
+	dc.l.Lock()
+	defer dc.l.Unlock()
+
+	r := heightRange(start, end)
+	if err := dc.deleteRange(r, 0); err != nil {
+		return err
+	}
+	return dc.db.CompactRange(*r)
+}
+
+func (dc *DiskCache) get(kind byte, height uint64) ([]byte, bool) {
+	if dc == nil {
+		return nil, false
+	}
+
+	v, err := dc.db.Get(cacheKey(kind, height), nil)
+	if err != nil {
+		diskCacheRequests.WithLabels(kindName(kind), "miss").Inc()
+		return nil, false
+	}
+	diskCacheRequests.WithLabels(kindName(kind), "hit").Inc()
+	return v, true
+}
+
+func (dc *DiskCache) put(kind byte, height uint64, v []byte) error {
+	dc.l.Lock()
+	defer dc.l.Unlock()
+
+	key := cacheKey(kind, height)
+	if old, err := dc.db.Get(key, nil); err == nil {
+		dc.size -= int64(len(key) + len(old))
+	}
+	if err := dc.db.Put(key, v, nil); err != nil {
+		return err
+	}
+	dc.size += int64(len(key) + len(v))
+
+	if dc.size > dc.maxSize {
+		// remove the lowest heights down to 90% of max size, so it's not done on every put
+		if err := dc.deleteRange(nil, dc.maxSize*9/10); err != nil {
+			return err
+		}
+		if err := dc.db.CompactRange(util.Range{}); err != nil {
+			return err
+		}
+	}
+	diskCacheSize.WithLabels().Set(float64(dc.size))
+	return nil
+}
+
+// deleteRange deletes entries in range from the lowest one, until size is not greater than target
+func (dc *DiskCache) deleteRange(r *util.Range, target int64) error {
+	batch := new(leveldb.Batch)
+	iter := dc.db.NewIterator(r, nil)
+	for dc.size > target && iter.Next() {
+		batch.Delete(iter.Key())
+		dc.size -= int64(len(iter.Key()) + len(iter.Value()))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	diskCacheSize.WithLabels().Set(float64(dc.size))
+	return dc.db.Write(batch, nil)
+}
+
+func kindName(kind byte) string {
+	switch kind {
+	case keyBlock:
+		return "block"
+	case keySignatures:
+		return "signatures"
+	case keyHeader:
+		return "header"
+	}
+	return "transactions"
+}
+
+func cacheKey(kind byte, height uint64) []byte {
+	key := make([]byte, 9)
+	binary.BigEndian.PutUint64(key, height)
+	key[8] = kind
+	return key
+}
+
+func heightRange(start, end uint64) *util.Range {
+	r := &util.Range{Start: cacheKey(0, start)}
+	if end < ^uint64(0) {
+		r.Limit = cacheKey(0, end+1)
+	}
+	return r
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache(t *testing.T) {
+	InitMetrics()
+
+	t.Run("persisted between opens", func(t *testing.T) {
+		dir := t.TempDir()
+		dc, err := OpenDiskCache(dir, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, dc.PutBlock(testBlock(5)))
+		require.NoError(t, dc.PutTxsEvent(5, &tx.GetTxsEventResponse{TxResponses: []*types.TxResponse{{Height: 5, TxHash: "TX"}}}))
+		size := dc.Size()
+		require.NoError(t, dc.Close())
+
+		dc, err = OpenDiskCache(dir, 0)
+		require.NoError(t, err)
+		defer dc.Close()
+		require.Equal(t, size, dc.Size())
+
+		bl, ok := dc.Block(5)
+		require.True(t, ok)
+		require.Equal(t, testBlock(5), bl)
+		res, ok := dc.TxsEvent(5)
+		require.True(t, ok)
+		require.Equal(t, "TX", res.TxResponses[0].TxHash)
+
+		_, ok = dc.Block(6)
+		require.False(t, ok)
+		_, ok = dc.TxsEvent(6)
+		require.False(t, ok)
+	})
+
+	t.Run("lowest heights removed over max size", func(t *testing.T) {
+		dc, err := OpenDiskCache(t.TempDir(), 1000)
+		require.NoError(t, err)
+		defer dc.Close()
+
+		for h := uint64(1); h <= 100; h++ {
+			require.NoError(t, dc.PutBlock(testBlock(h)))
+		}
+		require.LessOrEqual(t, dc.Size(), int64(1000))
+
+		_, ok := dc.Block(1)
+		require.False(t, ok)
+		_, ok = dc.Block(100)
+		require.True(t, ok)
+	})
+
+	t.Run("invalidate range", func(t *testing.T) {
+		dc, err := OpenDiskCache(t.TempDir(), 0)
+		require.NoError(t, err)
+		defer dc.Close()
+
+		for h := uint64(1); h <= 10; h++ {
+			require.NoError(t, dc.PutBlock(testBlock(h)))
+			require.NoError(t, dc.PutTxsEvent(h, &tx.GetTxsEventResponse{}))
+		}
+		require.NoError(t, dc.Invalidate(3, 5))
+		require.Error(t, dc.Invalidate(5, 3))
+
+		for h := uint64(1); h <= 10; h++ {
+			_, okBlock := dc.Block(h)
+			_, okTxs := dc.TxsEvent(h)
+			cached := h < 3 || h > 5
+			require.Equal(t, cached, okBlock, h)
+			require.Equal(t, cached, okTxs, h)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var dc *DiskCache
+		require.NoError(t, dc.PutBlock(testBlock(1)))
+		_, ok := dc.Block(1)
+		require.False(t, ok)
+		require.NoError(t, dc.Invalidate(1, 2))
+	})
+}
+
+func TestClientDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	chain := &fakeChain{chainID: "test-1", height: 10, txsPerBlock: 3}
+	fn := startFakeNode(t, chain)
+	ctx := context.Background()
+
+	fetch := func() (structs.Block, []structs.Transaction) {
+		dc, err := OpenDiskCache(dir, 0)
+		require.NoError(t, err)
+		defer dc.Close()
+
+		cli := newTestClient(t, &ClientConfig{}, fn)
+		cli.DiskCache = dc
+
+		block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+		require.NoError(t, err)
+		txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 5}, block, 2)
+		require.NoError(t, err)
+		return block, txs
+	}
+
+	block, txs := fetch()
+	served := fn.served()
+	require.Len(t, txs, 3)
+
+	// new client (restarted worker) gets the same from disk
+	cachedBlock, cachedTxs := fetch()
+	require.Equal(t, served, fn.served())
+	require.Equal(t, block, cachedBlock)
+	require.Equal(t, txs, cachedTxs)
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codec_types "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	slashingTypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeChain is a deterministic chain served by fake nodes
+type fakeChain struct {
+	chainID     string
+	height      int64
+	txsPerBlock int
+	validators  int
+	// absent makes validator miss the commit of given height
+	absent func(height int64, validator int) bool
+	// tipTime is the time of the latest block (default - deterministic past time)
+	tipTime time.Time
+	// signedBlocksWindow window of slashing module missed blocks are counted in (default - 100)
+	signedBlocksWindow int64
+	// forkFrom makes blocks from given height different than blocks of the chain without fork
+	forkFrom int64
+}
+
+func (fc *fakeChain) blockHash(height int64) []byte {
+	if fc.forkFrom > 0 && height >= fc.forkFrom {
+		return []byte(fmt.Sprintf("fork-%d", height))
+	}
+	return []byte(fmt.Sprintf("block-%d", height))
+}
+
+func (fc *fakeChain) block(height int64) (*tmproto.BlockID, *tmproto.Block) {
+	b := &tmproto.Block{
+		Header: tmproto.Header{
+			ChainID: fc.chainID,
+			Height:  height,
+			Time:    time.Unix(1600000000+height*6, 0).UTC(),
+			AppHash: []byte(fmt.Sprintf("app-%d", height)),
+		},
+	}
+	if height > 1 {
+		b.Header.LastBlockId = tmproto.BlockID{Hash: fc.blockHash(height - 1)}
+	}
+	if height == fc.height && !fc.tipTime.IsZero() {
+		b.Header.Time = fc.tipTime
+	}
+	if fc.validators > 0 {
+		b.Header.ProposerAddress = fc.validatorAddress(int(height) % fc.validators)
+	}
+	if fc.validators > 0 && height > 1 {
+		b.LastCommit = &tmproto.Commit{Height: height - 1}
+		for i := 0; i < fc.validators; i++ {
+			cs := tmproto.CommitSig{BlockIdFlag: tmproto.BlockIDFlagCommit, ValidatorAddress: fc.validatorAddress(i), Timestamp: b.Header.Time}
+			if fc.absent != nil && fc.absent(height-1, i) {
+				cs = tmproto.CommitSig{BlockIdFlag: tmproto.BlockIDFlagAbsent}
+			}
+			b.LastCommit.Signatures = append(b.LastCommit.Signatures, cs)
+		}
+	}
+	for i := 0; i < fc.txsPerBlock; i++ {
+		t, _ := fc.tx(height, i)
+		b.Data.Txs = append(b.Data.Txs, fc.rawTx(t))
+	}
+	return &tmproto.BlockID{Hash: fc.blockHash(height)}, b
+}
+
+func (fc *fakeChain) tx(height int64, index int) (*tx.Tx, *sdk.TxResponse) {
+	msg, err := codec_types.NewAnyWithValue(&bankTypes.MsgSend{
+		FromAddress: "cosmos1sender",
+		ToAddress:   "cosmos1recipient",
+		Amount:      sdk.NewCoins(sdk.NewInt64Coin("uatom", int64(index+1))),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	t := &tx.Tx{
+		Body:       &tx.TxBody{Messages: []*codec_types.Any{msg}, Memo: fmt.Sprintf("tx-%d-%d", height, index)},
+		AuthInfo:   &tx.AuthInfo{Fee: &tx.Fee{Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), GasLimit: 200000}},
+		Signatures: [][]byte{[]byte("signature")},
+	}
+	raw := fc.rawTx(t)
+	logs := sdk.ABCIMessageLogs{{MsgIndex: 0, Events: sdk.StringEvents{{Type: "message", Attributes: []sdk.Attribute{{Key: "action", Value: "send"}}}}}}
+
+	return t, &sdk.TxResponse{
+		Height:    height,
+		TxHash:    txHash(raw),
+		Tx:        &codec_types.Any{TypeUrl: "/cosmos.tx.v1beta1.Tx", Value: raw},
+		Logs:      logs,
+		RawLog:    logs.String(),
+		GasWanted: 200000,
+		GasUsed:   int64(100000 + index),
+	}
+}
+
+// rawTx encodes transaction as it is in block data
+func (fc *fakeChain) rawTx(t *tx.Tx) []byte {
+	body, err := t.Body.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	authInfo, err := t.AuthInfo.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	raw, err := (&tx.TxRaw{BodyBytes: body, AuthInfoBytes: authInfo, Signatures: t.Signatures}).Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// fakeNode is an in-process cosmos grpc node
+type fakeNode struct {
+	tmservice.UnimplementedServiceServer
+
+	chain    *fakeChain
+	addr     string
+	server   *grpc.Server
+	requests int64
+
+	// txsDelay is processing time of GetTxsEvent, txsInflight and txsMaxInflight track concurrent calls
+	txsDelay       time.Duration
+	txsInflight    int64
+	txsMaxInflight int64
+
+	l         sync.Mutex
+	failCode  codes.Code
+	failTimes int
+	earliest  int64
+	// stateEarliest prunes only application state
+	stateEarliest int64
+	syncing       bool
+}
+
+func startFakeNode(t testing.TB, chain *fakeChain, opts ...grpc.ServerOption) *fakeNode {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fn := &fakeNode{chain: chain, addr: lis.Addr().String(), server: grpc.NewServer(opts...)}
+	tmservice.RegisterServiceServer(fn.server, fn)
+	tx.RegisterServiceServer(fn.server, &fakeTxService{fn: fn})
+	bankTypes.RegisterQueryServer(fn.server, &fakeBankService{fn: fn})
+	stakingTypes.RegisterQueryServer(fn.server, &fakeStakingService{fn: fn})
+	slashingTypes.RegisterQueryServer(fn.server, &fakeSlashingService{fn: fn})
+
+	go fn.server.Serve(lis)
+	t.Cleanup(fn.server.Stop)
+	return fn
+}
+
+// setFailing makes node respond with given code to every call (codes.OK - stop failing)
+func (fn *fakeNode) setFailing(code codes.Code) {
+	fn.failNext(code, -1)
+}
+
+// failNext makes node respond with given code to the next n calls (negative - all)
+func (fn *fakeNode) failNext(code codes.Code, n int) {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	fn.failCode = code
+	fn.failTimes = n
+}
+
+func (fn *fakeNode) request() error {
+	atomic.AddInt64(&fn.requests, 1)
+
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	if fn.failCode == codes.OK || fn.failTimes == 0 {
+		return nil
+	}
+	if fn.failTimes > 0 {
+		fn.failTimes--
+	}
+	return status.Error(fn.failCode, "fake failure")
+}
+
+// setEarliest prunes node below given height
+func (fn *fakeNode) setEarliest(height int64) {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	fn.earliest = height
+}
+
+func (fn *fakeNode) pruned(height int64) bool {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	return height < fn.earliest
+}
+
+// setStateEarliest prunes application state below given height, keeping the blocks
+func (fn *fakeNode) setStateEarliest(height int64) {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	fn.stateEarliest = height
+}
+
+func (fn *fakeNode) statePruned(height int64) bool {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	return height < fn.earliest || height < fn.stateEarliest
+}
+
+func (fn *fakeNode) setSyncing(syncing bool) {
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	fn.syncing = syncing
+}
+
+func (fn *fakeNode) served() int64 {
+	return atomic.LoadInt64(&fn.requests)
+}
+
+func (fn *fakeNode) GetSyncing(ctx context.Context, req *tmservice.GetSyncingRequest) (*tmservice.GetSyncingResponse, error) {
+	if err := fn.request(); err != nil {
+		return nil, err
+	}
+	fn.l.Lock()
+	defer fn.l.Unlock()
+	return &tmservice.GetSyncingResponse{Syncing: fn.syncing}, nil
+}
+
+func (fn *fakeNode) GetLatestBlock(ctx context.Context, req *tmservice.GetLatestBlockRequest) (*tmservice.GetLatestBlockResponse, error) {
+	if err := fn.request(); err != nil {
+		return nil, err
+	}
+	id, b := fn.chain.block(fn.chain.height)
+	return &tmservice.GetLatestBlockResponse{BlockId: id, Block: b}, nil
+}
+
+func (fn *fakeNode) GetBlockByHeight(ctx context.Context, req *tmservice.GetBlockByHeightRequest) (*tmservice.GetBlockByHeightResponse, error) {
+	if err := fn.request(); err != nil {
+		return nil, err
+	}
+	if req.Height > fn.chain.height {
+		return nil, status.Error(codes.InvalidArgument, "requested block height is bigger then the chain length")
+	}
+	if fn.pruned(req.Height) {
+		return nil, status.Errorf(codes.Unknown, "height %d is not available, lowest height is %d", req.Height, fn.earliest)
+	}
+	id, b := fn.chain.block(req.Height)
+	return &tmservice.GetBlockByHeightResponse{BlockId: id, Block: b}, nil
+}
+
+type fakeTxService struct {
+	tx.UnimplementedServiceServer
+	fn *fakeNode
+}
+
+func (fts *fakeTxService) GetTxsEvent(ctx context.Context, req *tx.GetTxsEventRequest) (*tx.GetTxsEventResponse, error) {
+	if err := fts.fn.request(); err != nil {
+		return nil, err
+	}
+
+	inflight := atomic.AddInt64(&fts.fn.txsInflight, 1)
+	defer atomic.AddInt64(&fts.fn.txsInflight, -1)
+	for {
+		max := atomic.LoadInt64(&fts.fn.txsMaxInflight)
+		if inflight <= max || atomic.CompareAndSwapInt64(&fts.fn.txsMaxInflight, max, inflight) {
+			break
+		}
+	}
+	time.Sleep(fts.fn.txsDelay)
+
+	// every transaction of the chain is sent by cosmos1sender to cosmos1recipient
+	from, to := int64(1), fts.fn.chain.height
+	for _, ev := range req.Events {
+		switch {
+		case strings.Contains(ev, "'"):
+			return nil, status.Errorf(codes.Unknown, "failed to parse query: %s", ev)
+		case strings.HasPrefix(ev, "tx.height>="):
+			from, _ = strconv.ParseInt(strings.TrimPrefix(ev, "tx.height>="), 10, 64)
+		case strings.HasPrefix(ev, "tx.height<="):
+			to, _ = strconv.ParseInt(strings.TrimPrefix(ev, "tx.height<="), 10, 64)
+		case strings.HasPrefix(ev, "tx.height="):
+			from, _ = strconv.ParseInt(strings.TrimPrefix(ev, "tx.height="), 10, 64)
+			to = from
+		case ev == "message.sender=cosmos1sender", ev == "transfer.recipient=cosmos1recipient":
+		default:
+			to = from - 1
+		}
+	}
+
+	perBlock := int64(fts.fn.chain.txsPerBlock)
+	total := (to - from + 1) * perBlock
+	if total < 0 {
+		total = 0
+	}
+	resp := &tx.GetTxsEventResponse{Pagination: &query.PageResponse{Total: uint64(total)}}
+	for i := int64(req.Pagination.Offset); i < total && i < int64(req.Pagination.Offset+req.Pagination.Limit); i++ {
+		t, r := fts.fn.chain.tx(from+i/perBlock, int(i%perBlock))
+		resp.Txs = append(resp.Txs, t)
+		resp.TxResponses = append(resp.TxResponses, r)
+	}
+	return resp, nil
+}
+
+func (fts *fakeTxService) GetTx(ctx context.Context, req *tx.GetTxRequest) (*tx.GetTxResponse, error) {
+	if err := fts.fn.request(); err != nil {
+		return nil, err
+	}
+
+	for h := int64(1); h <= fts.fn.chain.height; h++ {
+		for i := 0; i < fts.fn.chain.txsPerBlock; i++ {
+			if t, r := fts.fn.chain.tx(h, i); r.TxHash == req.Hash {
+				return &tx.GetTxResponse{Tx: t, TxResponse: r}, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.Unknown, "tx (%s) not found", req.Hash)
+}
+
+type fakeBankService struct {
+	bankTypes.UnimplementedQueryServer
+	fn *fakeNode
+}
+
+func (fbs *fakeBankService) AllBalances(ctx context.Context, req *bankTypes.QueryAllBalancesRequest) (*bankTypes.QueryAllBalancesResponse, error) {
+	if err := fbs.fn.request(); err != nil {
+		return nil, err
+	}
+
+	var height int64
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if h := md.Get(grpctypes.GRPCBlockHeightHeader); len(h) > 0 {
+			height, _ = strconv.ParseInt(h[0], 10, 64)
+		}
+	}
+	if fbs.fn.statePruned(height) {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to load state at height %d; version does not exist (latest height: %d)", height, fbs.fn.chain.height)
+	}
+
+	return &bankTypes.QueryAllBalancesResponse{Balances: sdk.NewCoins(sdk.NewInt64Coin("uatom", height))}, nil
+}
+
+func newTestClient(t testing.TB, cfg *ClientConfig, nodes ...*fakeNode) *Client {
+	t.Helper()
+	InitMetrics()
+
+	var conns []*grpc.ClientConn
+	for _, fn := range nodes {
+		conn, err := grpc.Dial(fn.addr, grpc.WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		conns = append(conns, conn)
+	}
+
+	if cfg.ReqPerSecond == 0 {
+		cfg.ReqPerSecond = 1000
+	}
+	if cfg.TimeoutBlockCall == 0 {
+		cfg.TimeoutBlockCall = 5 * time.Second
+	}
+	if cfg.TimeoutSearchTxCall == 0 {
+		cfg.TimeoutSearchTxCall = 5 * time.Second
+	}
+
+	logger := zaptest.NewLogger(t)
+	if _, ok := t.(*testing.B); ok {
+		logger = zap.NewNop()
+	}
+	return NewClient(logger, conns, cfg)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"go.uber.org/zap"
+)
+
+// Reasons of fork notice
+const (
+	// ForkReasonHashMismatch block on the node has different hash than the one known by indexer
+	ForkReasonHashMismatch = "hash_mismatch"
+	// ForkReasonLastBlockIDMismatch block on the node doesn't point to the previous block streamed to indexer
+	ForkReasonLastBlockIDMismatch = "last_block_id_mismatch"
+)
+
+// ForkNotice tells that history of the chain on the node is not continuation of the one known by indexer.
+// Block of given height (and all above it) known by indexer should be rolled back.
+type ForkNotice struct {
+	Height uint64 `json:"height"`
+	// KnownHash hash of the block known by indexer
+	KnownHash string `json:"known_hash"`
+	// NodeHash hash of the block on the node
+	NodeHash string `json:"node_hash"`
+	Reason   string `json:"reason"`
+}
+
+// ErrBlockChanged is returned when block on the node is different than the one given to check,
+// which was taken from the cache of history that is no longer valid (or chain has just reorganized)
+var ErrBlockChanged = errors.New("block on the node has changed")
+
+// VerifyBlockHash checks if block of given height on the node has given hash, returns notice when it's different.
+// Block is always fetched from the node, cached one may come from the history which is no longer valid.
+func (c *Client) VerifyBlockHash(ctx context.Context, height uint64, hash string) (*ForkNotice, error) {
+	block, _, _, err := c.fetchBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(block.Hash, hash) {
+		return nil, nil
+	}
+
+	c.invalidateFrom(height)
+	forkNotices.WithLabels(ForkReasonHashMismatch).Inc()
+	return &ForkNotice{Height: height, KnownHash: hash, NodeHash: block.Hash, Reason: ForkReasonHashMismatch}, nil
+}
+
+// CheckContinuity checks if block of given height and hash points to the previous block of given hash (LastBlockID),
+// returns notice when it doesn't. Header fetched (or cached) with the block is checked first, only when it doesn't match
+// the block is fetched from the node again, as the cached one may come from the history which is no longer valid.
+// ErrBlockChanged is returned when block on the node is not the given one.
+func (c *Client) CheckContinuity(ctx context.Context, height uint64, hash, prevHash string) (*ForkNotice, error) {
+	block, err := c.GetBlock(ctx, structs.HeightHash{Height: height})
+	if err != nil {
+		return nil, err
+	}
+	header, err := c.GetBlockHeader(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(block.Hash, hash) && strings.EqualFold(header.LastBlockID.Hash, prevHash) {
+		return nil, nil
+	}
+
+	block, header, _, err = c.fetchBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(block.Hash, hash) {
+		c.invalidateFrom(height)
+		return nil, fmt.Errorf("%w: %d %s (node %s)", ErrBlockChanged, height, hash, block.Hash)
+	}
+	if strings.EqualFold(header.LastBlockID.Hash, prevHash) {
+		return nil, nil
+	}
+
+	c.invalidateFrom(height - 1)
+	forkNotices.WithLabels(ForkReasonLastBlockIDMismatch).Inc()
+	return &ForkNotice{Height: height - 1, KnownHash: prevHash, NodeHash: header.LastBlockID.Hash, Reason: ForkReasonLastBlockIDMismatch}, nil
+}
+
+// invalidateFrom removes cached blocks (with all their data) of given height and above, they belong to the history before fork
+func (c *Client) invalidateFrom(height uint64) {
+	c.Cache.InvalidateFrom(height)
+	if err := c.DiskCache.Invalidate(height, ^uint64(0)); err != nil {
+		c.logger.Warn("[COSMOS-API] Error invalidating disk cache", zap.Uint64("height", height), zap.Error(err))
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+func TestClientVerifyBlockHash(t *testing.T) {
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 20, forkFrom: 8})
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	notice, err := cli.VerifyBlockHash(ctx, 7, strings.ToLower(bytes.HexBytes("block-7").String()))
+	require.NoError(t, err)
+	require.Nil(t, notice)
+
+	// block is fetched from the node even when it's cached
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 9})
+	require.NoError(t, err)
+	served := fn.served()
+	notice, err = cli.VerifyBlockHash(ctx, 9, bytes.HexBytes("block-9").String())
+	require.NoError(t, err)
+	require.Equal(t, served+1, fn.served())
+	require.Equal(t, &ForkNotice{
+		Height:    9,
+		KnownHash: bytes.HexBytes("block-9").String(),
+		NodeHash:  bytes.HexBytes("fork-9").String(),
+		Reason:    ForkReasonHashMismatch,
+	}, notice)
+
+	_, err = cli.VerifyBlockHash(ctx, 30, bytes.HexBytes("block-30").String())
+	require.Error(t, err)
+}
+
+func TestClientCheckContinuity(t *testing.T) {
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 20, forkFrom: 8})
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	notice, err := cli.CheckContinuity(ctx, 8, bytes.HexBytes("fork-8").String(), bytes.HexBytes("block-7").String())
+	require.NoError(t, err)
+	require.Nil(t, notice)
+
+	// block fetched in range is checked by its header, without calling the node again
+	_, err = cli.GetBlock(ctx, structs.HeightHash{Height: 12})
+	require.NoError(t, err)
+	served := fn.served()
+	notice, err = cli.CheckContinuity(ctx, 12, bytes.HexBytes("fork-12").String(), bytes.HexBytes("fork-11").String())
+	require.NoError(t, err)
+	require.Nil(t, notice)
+	require.Equal(t, served, fn.served())
+
+	notice, err = cli.CheckContinuity(ctx, 11, bytes.HexBytes("fork-11").String(), bytes.HexBytes("fork-10").String())
+	require.NoError(t, err)
+	require.Nil(t, notice)
+
+	notice, err = cli.CheckContinuity(ctx, 10, bytes.HexBytes("fork-10").String(), bytes.HexBytes("block-9").String())
+	require.NoError(t, err)
+	require.Equal(t, &ForkNotice{
+		Height:    9,
+		KnownHash: bytes.HexBytes("block-9").String(),
+		NodeHash:  bytes.HexBytes("fork-9").String(),
+		Reason:    ForkReasonLastBlockIDMismatch,
+	}, notice)
+}
+
+func TestClientForkBypassesCache(t *testing.T) {
+	fn := startFakeNode(t, &fakeChain{chainID: "test-1", height: 20, forkFrom: 8})
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	dc, err := OpenDiskCache(t.TempDir(), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { dc.Close() })
+	cli.DiskCache = dc
+	ctx := context.Background()
+
+	// history before the fork is cached
+	stale := func(height uint64) (structs.Block, BlockHeader) {
+		bl := structs.Block{Height: height, Hash: bytes.HexBytes(fmt.Sprintf("block-%d", height)).String(), ChainID: "test-1"}
+		header := BlockHeader{Height: height, LastBlockID: BlockID{Hash: bytes.HexBytes(fmt.Sprintf("block-%d", height-1)).String()}}
+		return bl, header
+	}
+	for h := uint64(7); h <= 12; h++ {
+		bl, header := stale(h)
+		cli.Cache.Add(bl)
+		cli.Cache.AddHeader(h, header)
+		require.NoError(t, dc.PutBlock(bl))
+		require.NoError(t, dc.PutHeader(header))
+	}
+
+	t.Run("stale block", func(t *testing.T) {
+		bl, _ := stale(12)
+		_, err := cli.CheckContinuity(ctx, 12, bl.Hash, bytes.HexBytes("fork-11").String())
+		require.True(t, errors.Is(err, ErrBlockChanged))
+
+		_, ok := cli.Cache.Get(12)
+		require.False(t, ok)
+		_, ok = dc.Block(12)
+		require.False(t, ok)
+		_, ok = dc.Block(11)
+		require.True(t, ok)
+	})
+
+	t.Run("stale previous block", func(t *testing.T) {
+		served := fn.served()
+		notice, err := cli.CheckContinuity(ctx, 10, bytes.HexBytes("fork-10").String(), bytes.HexBytes("block-9").String())
+		require.NoError(t, err)
+		require.Equal(t, served+1, fn.served())
+		require.NotNil(t, notice)
+		require.Equal(t, uint64(9), notice.Height)
+
+		for h := uint64(9); h <= 11; h++ {
+			_, ok := cli.Cache.Get(h)
+			require.False(t, ok, "height %d", h)
+			_, ok = dc.Header(h)
+			require.False(t, ok, "height %d", h)
+		}
+		_, ok := cli.Cache.Get(8)
+		require.True(t, ok)
+		_, ok = dc.Block(8)
+		require.True(t, ok)
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		notice, err := cli.VerifyBlockHash(ctx, 8, bytes.HexBytes("block-8").String())
+		require.NoError(t, err)
+		require.NotNil(t, notice)
+
+		_, ok := cli.Cache.Get(8)
+		require.False(t, ok)
+		_, ok = dc.Block(8)
+		require.False(t, ok)
+		_, ok = cli.Cache.Get(7)
+		require.True(t, ok)
+	})
+}
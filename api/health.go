@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+)
+
+// BreakerProber reports state of the circuit breaker to health.Monitor,
+// worker is not ready while the breaker is open.
+type BreakerProber struct {
+	c *Client
+}
+
+// NewBreakerProber is BreakerProber constructor
+func NewBreakerProber(c *Client) *BreakerProber {
+	return &BreakerProber{c: c}
+}
+
+// Probe checks circuit breaker state
+func (bp *BreakerProber) Probe(ctx context.Context) error {
+	if st := bp.c.BreakerState(); st != BreakerClosed {
+		return fmt.Errorf("circuit breaker is %s: %w", st, ErrCircuitOpen)
+	}
+	return nil
+}
+
+// Readiness returns circuit breaker state
+func (bp *BreakerProber) Readiness(ctx context.Context) (probetype, readinesstype string, contents interface{}, err error) {
+	return "cosmos", "breaker", bp.c.BreakerState().String(), bp.Probe(ctx)
+}
+
+// EndpointSync is sync status of the endpoint
+type EndpointSync struct {
+	Address      string    `json:"address"`
+	Synced       bool      `json:"synced"`
+	CatchingUp   bool      `json:"catching_up"`
+	LatestHeight uint64    `json:"latest_height"`
+	LatestTime   time.Time `json:"latest_time"`
+	Lag          string    `json:"lag"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// SyncProber checks if cosmos nodes are in sync with the network.
+// Worker is not ready when none of the nodes is reachable, synced and producing fresh blocks.
+type SyncProber struct {
+	c      *Client
+	maxLag time.Duration
+
+	l       sync.RWMutex
+	probed  bool
+	lastErr error
+	status  []EndpointSync
+}
+
+// NewSyncProber is SyncProber constructor, maxLag is the maximum accepted age of the latest block
+func NewSyncProber(c *Client, maxLag time.Duration) *SyncProber {
+	return &SyncProber{c: c, maxLag: maxLag}
+}
+
+// Probe checks sync status of all the nodes
+func (sp *SyncProber) Probe(ctx context.Context) error {
+	status := make([]EndpointSync, 0, len(sp.c.pool.nodes))
+	var synced bool
+	for _, n := range sp.c.pool.nodes {
+		es := sp.c.syncStatus(ctx, n, sp.maxLag)
+		synced = synced || es.Synced
+		status = append(status, es)
+	}
+
+	var err error
+	if !synced {
+		err = errors.New("none of cosmos nodes is synced")
+	}
+
+	sp.l.Lock()
+	defer sp.l.Unlock()
+	sp.probed = true
+	sp.status = status
+	sp.lastErr = err
+	return err
+}
+
+// Readiness returns result of the last probe
+func (sp *SyncProber) Readiness(ctx context.Context) (probetype, readinesstype string, contents interface{}, err error) {
+	sp.l.RLock()
+	probed := sp.probed
+	sp.l.RUnlock()
+
+	if !probed {
+		sp.Probe(ctx)
+	}
+
+	sp.l.RLock()
+	defer sp.l.RUnlock()
+	return "cosmos", "sync", sp.status, sp.lastErr
+}
+
+// syncStatus checks if node is reachable, not catching up and its latest block is not older than maxLag
+func (c *Client) syncStatus(ctx context.Context, n *node, maxLag time.Duration) (es EndpointSync) {
+	es.Address = n.address
+
+	nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+	defer cancel()
+
+	if err := n.limiter.Wait(nctx); err != nil {
+		es.Error = err.Error()
+		return es
+	}
+	syncing, err := n.tmServiceClient.GetSyncing(nctx, &tmservice.GetSyncingRequest{})
+	if err != nil {
+		es.Error = err.Error()
+		return es
+	}
+	es.CatchingUp = syncing.Syncing
+
+	if err := n.limiter.Wait(nctx); err != nil {
+		es.Error = err.Error()
+		return es
+	}
+	lb, err := n.tmServiceClient.GetLatestBlock(nctx, &tmservice.GetLatestBlockRequest{})
+	if err != nil {
+		es.Error = err.Error()
+		return es
+	}
+	es.LatestHeight = uint64(lb.Block.Header.Height)
+	es.LatestTime = lb.Block.Header.Time
+	lag := time.Since(es.LatestTime)
+	es.Lag = lag.String()
+	n.setLatestHeight(es.LatestHeight)
+
+	es.Synced = !es.CatchingUp && (maxLag <= 0 || lag <= maxLag)
+	if !es.Synced {
+		endpointNotSynced.WithLabels(n.address).Inc()
+	}
+	return es
+}
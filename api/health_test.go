@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestSyncProber(t *testing.T) {
+	fresh := &fakeChain{chainID: "test-1", height: 100, tipTime: time.Now()}
+	stale := &fakeChain{chainID: "test-1", height: 90}
+
+	tests := []struct {
+		name       string
+		chain      *fakeChain
+		syncing    bool
+		failing    bool
+		wantSynced bool
+	}{
+		{name: "synced", chain: fresh, wantSynced: true},
+		{name: "catching up", chain: fresh, syncing: true},
+		{name: "stale latest block", chain: stale},
+		{name: "unreachable", chain: fresh, failing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := startFakeNode(t, tt.chain)
+			fn.setSyncing(tt.syncing)
+			if tt.failing {
+				fn.setFailing(codes.Unavailable)
+			}
+			cli := newTestClient(t, &ClientConfig{}, fn)
+			sp := NewSyncProber(cli, time.Minute)
+
+			_, _, contents, err := sp.Readiness(context.Background())
+			require.Equal(t, tt.wantSynced, err == nil)
+
+			status := contents.([]EndpointSync)
+			require.Len(t, status, 1)
+			require.Equal(t, tt.wantSynced, status[0].Synced)
+			require.Equal(t, tt.syncing, status[0].CatchingUp)
+			require.Equal(t, tt.failing, status[0].Error != "")
+		})
+	}
+}
+
+func TestSyncProberAnyNodeSynced(t *testing.T) {
+	synced := startFakeNode(t, &fakeChain{chainID: "test-1", height: 100, tipTime: time.Now()})
+	syncing := startFakeNode(t, &fakeChain{chainID: "test-1", height: 50})
+	syncing.setSyncing(true)
+
+	cli := newTestClient(t, &ClientConfig{}, syncing, synced)
+	sp := NewSyncProber(cli, time.Minute)
+	require.NoError(t, sp.Probe(context.Background()))
+
+	synced.setFailing(codes.Unavailable)
+	require.Error(t, sp.Probe(context.Background()))
+}
@@ -0,0 +1,160 @@
+package mapper
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/figment-networks/cosmos-worker/api/util"
+	shared "github.com/figment-networks/indexer-manager/structs"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// blockEventModules maps types of events emitted in BeginBlock and EndBlock to modules
+var blockEventModules = map[string]string{
+	"transfer":              "bank",
+	"coin_spent":            "bank",
+	"coin_received":         "bank",
+	"coinbase":              "bank",
+	"burn":                  "bank",
+	"mint":                  "mint",
+	"proposer_reward":       "distribution",
+	"commission":            "distribution",
+	"rewards":               "distribution",
+	"slash":                 "slashing",
+	"liveness":              "slashing",
+	"complete_unbonding":    "staking",
+	"complete_redelegation": "staking",
+	"active_proposal":       "gov",
+	"inactive_proposal":     "gov",
+}
+
+// blockEventAccounts are attributes holding accounts, with the role they have in event
+var blockEventAccounts = map[string]string{
+	"sender":                "sender",
+	"recipient":             "recipient",
+	"spender":               "sender",
+	"receiver":              "recipient",
+	"minter":                "recipient",
+	"burner":                "sender",
+	"validator":             "validator",
+	"delegator":             "delegator",
+	"source_validator":      "source_validator",
+	"destination_validator": "destination_validator",
+}
+
+// BlockEventToSub transforms event emitted in BeginBlock or EndBlock to SubsetEvent
+func BlockEventToSub(ev types.StringEvent) (se shared.SubsetEvent, err error) {
+	se = shared.SubsetEvent{
+		Type:   []string{ev.Type},
+		Module: blockEventModules[ev.Type],
+	}
+
+	var amounts []shared.TransactionAmount
+	for _, attr := range ev.Attributes {
+		switch attr.Key {
+		case "amount":
+			if attr.Value == "" {
+				continue
+			}
+			if amounts, err = parseAmounts(attr.Value); err != nil {
+				return se, err
+			}
+			if len(amounts) > 0 && se.Amount == nil {
+				se.Amount = map[string]shared.TransactionAmount{}
+			}
+			for i, amt := range amounts {
+				key := ev.Type
+				if i > 0 {
+					key += "_" + strconv.Itoa(i)
+				}
+				se.Amount[key] = amt
+			}
+		case "module":
+			if se.Module == "" {
+				se.Module = attr.Value
+			}
+		case "address":
+			// slashing events identify validator by consensus address
+			if se.Module == "slashing" {
+				addAccount(&se, "validator", attr.Value)
+				continue
+			}
+			addAdditional(&se, attr.Key, attr.Value)
+		default:
+			role, ok := blockEventAccounts[attr.Key]
+			if !ok {
+				addAdditional(&se, attr.Key, attr.Value)
+				continue
+			}
+			addAccount(&se, role, attr.Value)
+			switch role {
+			case "sender":
+				se.Sender = append(se.Sender, shared.EventTransfer{Account: shared.Account{ID: attr.Value}})
+			case "recipient":
+				se.Recipient = append(se.Recipient, shared.EventTransfer{Account: shared.Account{ID: attr.Value}})
+			}
+		}
+	}
+
+	// amount belongs to transfer's parties
+	if len(amounts) > 0 {
+		for i := range se.Sender {
+			se.Sender[i].Amounts = amounts
+		}
+		for i := range se.Recipient {
+			se.Recipient[i].Amounts = amounts
+		}
+		if len(se.Recipient) > 0 {
+			se.Transfers = map[string][]shared.EventTransfer{"send": se.Recipient}
+		}
+	}
+
+	return se, nil
+}
+
+func addAccount(se *shared.SubsetEvent, role, id string) {
+	if se.Node == nil {
+		se.Node = map[string][]shared.Account{}
+	}
+	se.Node[role] = append(se.Node[role], shared.Account{ID: id})
+}
+
+func addAdditional(se *shared.SubsetEvent, key, value string) {
+	if se.Additional == nil {
+		se.Additional = map[string][]string{}
+	}
+	se.Additional[key] = append(se.Additional[key], value)
+}
+
+// parseAmounts parses comma separated list of amounts like "10uatom,5.5ibc/27394FB"
+func parseAmounts(value string) (amts []shared.TransactionAmount, err error) {
+	for _, amt := range strings.Split(value, ",") {
+		attrAmt := shared.TransactionAmount{Numeric: &big.Int{}}
+
+		sliced := util.GetCurrency(amt)
+		var (
+			c       *big.Int
+			exp     int32
+			coinErr error
+		)
+		if len(sliced) == 3 {
+			attrAmt.Currency = sliced[2]
+			c, exp, coinErr = util.GetCoin(sliced[1])
+		} else {
+			c, exp, coinErr = util.GetCoin(amt)
+		}
+		if coinErr != nil {
+			return nil, fmt.Errorf("[COSMOS-API] Error parsing amount '%s': %s ", amt, coinErr)
+		}
+
+		attrAmt.Text = amt
+		attrAmt.Exp = exp
+		attrAmt.Numeric.Set(c)
+
+		amts = append(amts, attrAmt)
+	}
+	return amts, nil
+}
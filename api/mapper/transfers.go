@@ -1,13 +1,6 @@
 package mapper
 
 import (
-	"fmt"
-	"math/big"
-	"strings"
-
-	// "github.com/figment-networks/cosmos-worker/api/types"
-	// "github.com/figment-networks/cosmos-worker/api/util"
-	"github.com/figment-networks/cosmos-worker/api/util"
 	"github.com/figment-networks/indexer-manager/structs"
 
 	"github.com/cosmos/cosmos-sdk/types"
@@ -33,31 +26,9 @@ func produceTransfers(se *structs.SubsetEvent, transferType, skipAddr string, lg
 			}
 
 			if attr.Key == "amount" {
-				amts := []structs.TransactionAmount{}
-				for _, amt := range strings.Split(attr.Value, ",") {
-					attrAmt := structs.TransactionAmount{Numeric: &big.Int{}}
-
-					sliced := util.GetCurrency(amt)
-					var (
-						c       *big.Int
-						exp     int32
-						coinErr error
-					)
-					if len(sliced) == 3 {
-						attrAmt.Currency = sliced[2]
-						c, exp, coinErr = util.GetCoin(sliced[1])
-					} else {
-						c, exp, coinErr = util.GetCoin(amt)
-					}
-					if coinErr != nil {
-						return fmt.Errorf("[COSMOS-API] Error parsing amount '%s': %s ", amt, coinErr)
-					}
-
-					attrAmt.Text = amt
-					attrAmt.Exp = exp
-					attrAmt.Numeric.Set(c)
-
-					amts = append(amts, attrAmt)
+				amts, err := parseAmounts(attr.Value)
+				if err != nil {
+					return err
 				}
 				evts = append(evts, structs.EventTransfer{
 					Amounts: amts,
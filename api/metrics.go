@@ -59,6 +59,151 @@ var (
 		Tags:      []string{"type"},
 	})
 
+	endpointHealthy = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_healthy",
+		Desc:      "Whether endpoint is currently in the pool (1) or evicted (0)",
+		Tags:      []string{"endpoint"},
+	})
+
+	endpointHeight = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_height",
+		Desc:      "Latest height seen on endpoint",
+		Tags:      []string{"endpoint"},
+	})
+
+	endpointEarliestHeight = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_earliest_height",
+		Desc:      "Earliest height available on endpoint",
+		Tags:      []string{"endpoint"},
+	})
+
+	endpointEarliestStateHeight = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_earliest_state_height",
+		Desc:      "Earliest height of application state available on endpoint",
+		Tags:      []string{"endpoint"},
+	})
+
+	endpointFailovers = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_failovers",
+		Desc:      "Number of calls that failed on endpoint and were moved to another one",
+		Tags:      []string{"endpoint"},
+	})
+
+	endpointRateLimit = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_rate_limit",
+		Desc:      "Currently allowed number of requests per second to endpoint",
+		Tags:      []string{"endpoint"},
+	})
+
+	blockCacheRequests = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "block_cache_requests",
+		Desc:      "Number of block cache lookups by key (height, hash, latest, header, signatures, txs) and result (hit, miss)",
+		Tags:      []string{"key", "result"},
+	})
+
+	blockCacheEvictions = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "block_cache_evictions",
+		Desc:      "Number of blocks evicted from the block cache",
+	})
+
+	accountCacheRequests = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "account_cache_requests",
+		Desc:      "Number of account query cache lookups by query and result (hit, miss)",
+		Tags:      []string{"query", "result"},
+	})
+
+	accountCacheEvictions = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "account_cache_evictions",
+		Desc:      "Number of results evicted from the account query cache",
+	})
+
+	diskCacheRequests = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "disk_cache_requests",
+		Desc:      "Number of disk cache lookups by kind (block, header, transactions, signatures) and result (hit, miss)",
+		Tags:      []string{"kind", "result"},
+	})
+
+	diskCacheSize = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "disk_cache_size",
+		Desc:      "Approximate size of data in disk cache in bytes",
+	})
+
+	requestRetries = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "request_retries",
+		Desc:      "Number of retried calls to cosmos",
+		Tags:      []string{"endpoint", "code"},
+	})
+
+	endpointNotSynced = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "endpoint_not_synced",
+		Desc:      "Number of health checks that found endpoint catching up or behind the network",
+		Tags:      []string{"endpoint"},
+	})
+
+	breakerState = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "breaker_state",
+		Desc:      "State of circuit breaker (0 - closed, 1 - open, 2 - half-open)",
+	})
+
+	breakerRejections = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "breaker_rejections",
+		Desc:      "Number of calls rejected by open circuit breaker",
+	})
+
+	txSourceFallbacks = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "tx_source_fallbacks",
+		Desc:      "Number of blocks which transactions couldn't be decoded from block data and were fetched with GetTxsEvent",
+	})
+
+	newBlockSubscriptionReconnects = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "new_block_subscription_reconnects",
+		Desc:      "Number of times websocket subscription to new blocks was lost and reestablished",
+	})
+
+	forkNotices = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "api",
+		Name:      "fork_notices",
+		Desc:      "Number of detected discontinuities of chain history",
+		Tags:      []string{"reason"},
+	})
+
 	numberOfItemsTransactions *metrics.GroupCounter
 	numberOfItemsInBlock      *metrics.GroupCounter
 )
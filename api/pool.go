@@ -0,0 +1,359 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distributionTypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingTypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	grpc1 "github.com/gogo/protobuf/grpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultEndpointMaxFailures = 3
+	defaultEndpointCooldown    = 10 * time.Second
+
+	// weight of the newest sample in moving averages
+	ewmaWeight = 0.2
+)
+
+var errNoEndpoints = errors.New("no cosmos endpoints configured")
+
+// EndpointStatus is a snapshot of endpoint health
+type EndpointStatus struct {
+	Address             string        `json:"address"`
+	Healthy             bool          `json:"healthy"`
+	Latency             time.Duration `json:"latency"`
+	ErrorRate           float64       `json:"error_rate"`
+	LatestHeight        uint64        `json:"latest_height"`
+	EarliestHeight      uint64        `json:"earliest_height"`
+	EarliestStateHeight uint64        `json:"earliest_state_height"`
+}
+
+// node is a single cosmos grpc endpoint with its service clients and health stats
+type node struct {
+	address string
+
+	txServiceClient    tx.ServiceClient
+	tmServiceClient    tmservice.ServiceClient
+	bankClient         bankTypes.QueryClient
+	distributionClient distributionTypes.QueryClient
+	stakingClient      stakingTypes.QueryClient
+	slashingClient     slashingTypes.QueryClient
+
+	limiter *adaptiveLimiter
+
+	l                   sync.RWMutex
+	healthy             bool
+	latency             time.Duration
+	errorRate           float64
+	consecutiveFailures int
+	latestHeight        uint64
+	earliestHeight      uint64
+	earliestStateHeight uint64
+	failedAt            time.Time
+}
+
+func newNode(conn *grpc.ClientConn, opts []grpc.CallOption, reqPerSecond int, reserve float64) *node {
+	var cc grpc1.ClientConn = conn
+	if len(opts) > 0 {
+		cc = callOptionsConn{ClientConn: conn, opts: opts}
+	}
+
+	return &node{
+		address:            conn.Target(),
+		healthy:            true,
+		limiter:            newAdaptiveLimiter(conn.Target(), reqPerSecond, reserve),
+		tmServiceClient:    tmservice.NewServiceClient(cc),
+		txServiceClient:    tx.NewServiceClient(cc),
+		bankClient:         bankTypes.NewQueryClient(cc),
+		distributionClient: distributionTypes.NewQueryClient(cc),
+		stakingClient:      stakingTypes.NewQueryClient(cc),
+		slashingClient:     slashingTypes.NewQueryClient(cc),
+	}
+}
+
+// success records successful call, (re)admitting node to the pool
+func (n *node) success(d time.Duration) {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	if n.latency == 0 {
+		n.latency = d
+	} else {
+		n.latency = time.Duration(float64(n.latency)*(1-ewmaWeight) + float64(d)*ewmaWeight)
+	}
+	n.errorRate = n.errorRate * (1 - ewmaWeight)
+	n.consecutiveFailures = 0
+	n.healthy = true
+	endpointHealthy.WithLabels(n.address).Set(1)
+}
+
+// failure records failed call, evicting node from the pool after maxFailures in a row.
+// Unavailable nodes are evicted immediately as there is no connection to them.
+func (n *node) failure(err error, maxFailures int) {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	n.errorRate = n.errorRate*(1-ewmaWeight) + ewmaWeight
+	n.consecutiveFailures++
+	if n.consecutiveFailures >= maxFailures || status.Code(err) == codes.Unavailable {
+		n.healthy = false
+		n.failedAt = time.Now()
+		endpointHealthy.WithLabels(n.address).Set(0)
+	}
+}
+
+// setLatestHeight updates the latest height seen on node
+func (n *node) setLatestHeight(height uint64) {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	if height > n.latestHeight {
+		n.latestHeight = height
+		endpointHeight.WithLabels(n.address).Set(float64(height))
+	}
+}
+
+func (n *node) status() EndpointStatus {
+	n.l.RLock()
+	defer n.l.RUnlock()
+
+	return EndpointStatus{
+		Address:             n.address,
+		Healthy:             n.healthy,
+		Latency:             n.latency,
+		ErrorRate:           n.errorRate,
+		LatestHeight:        n.latestHeight,
+		EarliestHeight:      n.earliestHeight,
+		EarliestStateHeight: n.earliestStateHeight,
+	}
+}
+
+// score is the cost of using node, the lower the better
+func (n *node) score() float64 {
+	return float64(n.latency) * (1 + 10*n.errorRate)
+}
+
+// nodePool is a set of interchangeable cosmos endpoints
+type nodePool struct {
+	nodes       []*node
+	maxFailures int
+	cooldown    time.Duration
+}
+
+func newNodePool(conns []*grpc.ClientConn, opts []grpc.CallOption, cfg *ClientConfig) *nodePool {
+	maxFailures, cooldown := cfg.EndpointMaxFailures, cfg.EndpointCooldown
+	if maxFailures <= 0 {
+		maxFailures = defaultEndpointMaxFailures
+	}
+	if cooldown <= 0 {
+		cooldown = defaultEndpointCooldown
+	}
+
+	np := &nodePool{maxFailures: maxFailures, cooldown: cooldown}
+	for _, conn := range conns {
+		np.nodes = append(np.nodes, newNode(conn, opts, cfg.ReqPerSecond, cfg.PriorityReserve))
+	}
+	return np
+}
+
+// candidates returns nodes in order they should be tried for request at given height (0 - any),
+// state requests are routed by the earliest state height, the rest by the earliest block.
+// Healthy nodes and the ones after cooldown (trial call) go first. Out of them pruned nodes
+// are preferred over archive ones (the ones with the lowest earliest height), then ordered by score.
+// Nodes that are known not to have the height (yet or anymore) go after them.
+// Evicted nodes are returned last (longest evicted first), so a call is never refused up front.
+func (np *nodePool) candidates(height uint64, state bool) []*node {
+	type candidate struct {
+		n        *node
+		class    int
+		archive  bool
+		score    float64
+		failedAt time.Time
+	}
+
+	now := time.Now()
+	var minEarliest uint64
+	cs := make([]candidate, 0, len(np.nodes))
+	for _, n := range np.nodes {
+		n.l.RLock()
+		c := candidate{n: n, score: n.score(), failedAt: n.failedAt}
+		switch {
+		case !n.healthy && now.Sub(n.failedAt) < np.cooldown:
+			c.class = 2
+		case height > 0 && n.latestHeight > 0 && n.latestHeight < height:
+			c.class = 1
+		case height > 0 && n.earliest(state) > height:
+			c.class = 1
+		}
+		if earliest := n.earliest(state); earliest > 0 && (minEarliest == 0 || earliest < minEarliest) {
+			minEarliest = earliest
+		}
+		n.l.RUnlock()
+		cs = append(cs, c)
+	}
+
+	for i, c := range cs {
+		c.n.l.RLock()
+		earliest := c.n.earliest(state)
+		cs[i].archive = earliest > 0 && earliest == minEarliest
+		c.n.l.RUnlock()
+	}
+
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].class != cs[j].class {
+			return cs[i].class < cs[j].class
+		}
+		if cs[i].class == 2 {
+			return cs[i].failedAt.Before(cs[j].failedAt)
+		}
+		if cs[i].archive != cs[j].archive {
+			return !cs[i].archive
+		}
+		return cs[i].score < cs[j].score
+	})
+
+	nodes := make([]*node, len(cs))
+	for i, c := range cs {
+		nodes[i] = c.n
+	}
+	return nodes
+}
+
+// latestHeight returns the highest block seen on any node
+func (np *nodePool) latestHeight() (height uint64) {
+	for _, n := range np.nodes {
+		n.l.RLock()
+		if n.latestHeight > height {
+			height = n.latestHeight
+		}
+		n.l.RUnlock()
+	}
+	return height
+}
+
+// waitForReady returns call option making requests wait for connection.
+// With more than one endpoint it's better to fail fast and try another one.
+func (np *nodePool) waitForReady() grpc.CallOption {
+	return grpc.WaitForReady(len(np.nodes) == 1)
+}
+
+// callNodes runs fn against the best available node, failing over to the next one
+// when node is not able to serve the request
+func (c *Client) callNodes(ctx context.Context, endpoint string, height uint64, fn func(n *node) error) (err error) {
+	state := stateCalls[endpoint]
+	nodes := c.pool.candidates(height, state)
+	if len(nodes) == 0 {
+		return errNoEndpoints
+	}
+
+	for _, n := range nodes {
+		if err = n.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = fn(n)
+		d := time.Since(now)
+		n.limiter.adapt(endpoint, d, err)
+		if err == nil {
+			rawRequestGRPCDuration.WithLabels(endpoint, "ok").Observe(d.Seconds())
+			n.success(d)
+			return nil
+		}
+		rawRequestGRPCDuration.WithLabels(endpoint, "error").Observe(d.Seconds())
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if lowest, ok := prunedHeight(err); ok && height > 0 {
+			// node doesn't have the height anymore, the next one may be an archive
+			if lowest == 0 {
+				lowest = height + 1
+			}
+			n.raiseEarliestHeight(lowest, state)
+			c.logger.Debug("[COSMOS-API] Height pruned on endpoint", zap.String("endpoint", n.address), zap.String("call", endpoint), zap.Uint64("height", height))
+			continue
+		}
+
+		if !isNodeError(err) {
+			// node responded, it's the request that is wrong
+			n.success(d)
+			return err
+		}
+
+		if isRetryable(err) {
+			// only availability failures evict the node, it may fail just this call
+			n.failure(err, c.pool.maxFailures)
+		}
+		endpointFailovers.WithLabels(n.address).Inc()
+		c.logger.Warn("[COSMOS-API] Endpoint failed", zap.String("endpoint", n.address), zap.String("call", endpoint), zap.Error(err))
+	}
+
+	return err
+}
+
+// Endpoints returns current status of all endpoints
+func (c *Client) Endpoints() []EndpointStatus {
+	st := make([]EndpointStatus, 0, len(c.pool.nodes))
+	for _, n := range c.pool.nodes {
+		st = append(st, n.status())
+	}
+	return st
+}
+
+// MonitorEndpoints periodically checks latest and earliest block on every endpoint
+// updating its heights and (re)admitting it to the pool
+func (c *Client) MonitorEndpoints(ctx context.Context, interval time.Duration) {
+	tckr := time.NewTicker(interval)
+	defer tckr.Stop()
+
+	for {
+		for _, n := range c.pool.nodes {
+			c.checkEndpoint(ctx, n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tckr.C:
+		}
+	}
+}
+
+func (c *Client) checkEndpoint(ctx context.Context, n *node) {
+	nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+	defer cancel()
+
+	if err := n.limiter.Wait(nctx); err != nil {
+		return
+	}
+
+	now := time.Now()
+	lb, err := n.tmServiceClient.GetLatestBlock(nctx, &tmservice.GetLatestBlockRequest{})
+	n.limiter.adapt("GetLatestBlock", time.Since(now), err)
+	if err != nil {
+		if ctx.Err() == nil {
+			n.failure(err, c.pool.maxFailures)
+			c.logger.Debug("[COSMOS-API] Endpoint check failed", zap.String("endpoint", n.address), zap.Error(err))
+		}
+		return
+	}
+	n.success(time.Since(now))
+	n.setLatestHeight(uint64(lb.Block.Header.Height))
+
+	c.probeEarliestHeight(ctx, n)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientFailoverMidRange(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 40, txsPerBlock: 3}
+	nodes := []*fakeNode{startFakeNode(t, chain), startFakeNode(t, chain), startFakeNode(t, chain)}
+	cli := newTestClient(t, &ClientConfig{EndpointCooldown: time.Minute}, nodes...)
+
+	ctx := context.Background()
+	var killed *fakeNode
+	for h := uint64(1); h <= uint64(chain.height); h++ {
+		if h == 15 {
+			// kill the node that is going to serve next height
+			next := cli.pool.candidates(h, false)[0]
+			for _, fn := range nodes {
+				if fn.addr == next.address {
+					killed = fn
+				}
+			}
+			killed.server.Stop()
+		}
+
+		block, err := cli.GetBlock(ctx, structs.HeightHash{Height: h})
+		require.NoError(t, err)
+		require.Equal(t, h, block.Height)
+		require.Equal(t, uint64(chain.txsPerBlock), block.NumberOfTransactions)
+
+		txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: h}, block, 2)
+		require.NoError(t, err)
+		require.Len(t, txs, chain.txsPerBlock)
+		for _, tx := range txs {
+			require.Equal(t, h, tx.Height)
+			require.Equal(t, block.Hash, tx.BlockHash)
+		}
+	}
+
+	for _, st := range cli.Endpoints() {
+		require.Equal(t, st.Address != killed.addr, st.Healthy, st.Address)
+	}
+}
+
+func TestClientEndpointReadmission(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	flaky, stable := startFakeNode(t, chain), startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{EndpointCooldown: 20 * time.Millisecond}, flaky, stable)
+
+	flaky.setFailing(codes.Unavailable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), block.Height)
+	require.False(t, cli.Endpoints()[0].Healthy)
+	require.True(t, cli.Endpoints()[1].Healthy)
+
+	flaky.setFailing(codes.OK)
+	go cli.MonitorEndpoints(ctx, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		st := cli.Endpoints()[0]
+		return st.Healthy && st.LatestHeight == uint64(chain.height)
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestClientNoFailoverOnRequestError(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	nodes := []*fakeNode{startFakeNode(t, chain), startFakeNode(t, chain)}
+	cli := newTestClient(t, &ClientConfig{}, nodes...)
+
+	_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 100})
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, int64(1), nodes[0].served()+nodes[1].served())
+
+	for _, st := range cli.Endpoints() {
+		require.True(t, st.Healthy)
+	}
+}
+
+func TestClientNodeErrorDoesNotEvict(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	nodes := []*fakeNode{startFakeNode(t, chain), startFakeNode(t, chain)}
+	cli := newTestClient(t, &ClientConfig{EndpointMaxFailures: 1, BreakerThreshold: 100}, nodes...)
+	for _, fn := range nodes {
+		fn.setFailing(codes.Internal)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+		require.Equal(t, codes.Internal, status.Code(err))
+	}
+	require.Equal(t, int64(6), nodes[0].served()+nodes[1].served())
+	for _, st := range cli.Endpoints() {
+		require.True(t, st.Healthy)
+	}
+}
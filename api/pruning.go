@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+var lowestHeightRegex = regexp.MustCompile(`lowest height is (\d+)`)
+
+// stateCalls are calls served from application state, which is pruned separately from blocks
+var stateCalls = map[string]bool{
+	"AllBalances":          true,
+	"DelegatorDelegations": true,
+	"DelegatorValidators":  true,
+	"DelegationRewards":    true,
+	"Validators":           true,
+	"SlashingParams":       true,
+	"SigningInfos":         true,
+}
+
+// prunedHeight checks if error means that node doesn't have data for requested height (anymore).
+// Returns the lowest available height when node reported it.
+func prunedHeight(err error) (lowest uint64, pruned bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	msg := status.Convert(err).Message()
+	if m := lowestHeightRegex.FindStringSubmatch(msg); len(m) == 2 {
+		lowest, _ = strconv.ParseUint(m[1], 10, 64)
+		return lowest, true
+	}
+
+	// state pruned by application (iavl) - "failed to load state at height X; version does not exist"
+	return 0, strings.Contains(msg, "version does not exist") || strings.Contains(msg, "failed to load state at height")
+}
+
+// setEarliestHeight sets the earliest height node is able to serve
+func (n *node) setEarliestHeight(height uint64) {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	n.earliestHeight = height
+	endpointEarliestHeight.WithLabels(n.address).Set(float64(height))
+}
+
+// raiseEarliestHeight moves the earliest height node is able to serve up (pruning only goes forward).
+// Blocks and application state are pruned separately, state height is raised for state calls.
+func (n *node) raiseEarliestHeight(height uint64, state bool) {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	if state {
+		if height > n.earliestStateHeight {
+			n.earliestStateHeight = height
+			endpointEarliestStateHeight.WithLabels(n.address).Set(float64(height))
+		}
+		return
+	}
+	if height > n.earliestHeight {
+		n.earliestHeight = height
+		endpointEarliestHeight.WithLabels(n.address).Set(float64(height))
+	}
+}
+
+// earliest returns the earliest height of blocks or state node is expected to have, has to be called with lock held.
+// State is not kept for heights without blocks.
+func (n *node) earliest(state bool) uint64 {
+	if state && n.earliestStateHeight > n.earliestHeight {
+		return n.earliestStateHeight
+	}
+	return n.earliestHeight
+}
+
+// probeEarliestHeight finds the earliest block available on node.
+// Known value is verified first, the (expensive) search is done only when node doesn't report it in error.
+func (c *Client) probeEarliestHeight(ctx context.Context, n *node) {
+	n.l.RLock()
+	low, high := n.earliestHeight, n.latestHeight
+	n.l.RUnlock()
+
+	if low == 0 {
+		low = 1
+	}
+
+	available, lowest, err := c.blockAvailable(ctx, n, low)
+	switch {
+	case err != nil:
+		return
+	case available:
+		n.setEarliestHeight(low)
+		return
+	case lowest > 0:
+		n.setEarliestHeight(lowest)
+		return
+	}
+
+	if high <= low {
+		return
+	}
+
+	// binary search for the first available block in (low, high]
+	for low+1 < high {
+		mid := low + (high-low)/2
+		available, lowest, err = c.blockAvailable(ctx, n, mid)
+		if err != nil {
+			return
+		}
+		if lowest > 0 {
+			n.setEarliestHeight(lowest)
+			return
+		}
+		if available {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	c.logger.Debug("[COSMOS-API] Endpoint earliest height found", zap.String("endpoint", n.address), zap.Uint64("height", high))
+	n.setEarliestHeight(high)
+}
+
+// blockAvailable checks if block is available on the node
+func (c *Client) blockAvailable(ctx context.Context, n *node, height uint64) (available bool, lowest uint64, err error) {
+	nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+	defer cancel()
+
+	if err = n.limiter.Wait(nctx); err != nil {
+		return false, 0, err
+	}
+
+	now := time.Now()
+	_, err = n.tmServiceClient.GetBlockByHeight(nctx, &tmservice.GetBlockByHeightRequest{Height: int64(height)})
+	n.limiter.adapt("GetBlockByHeight", time.Since(now), err)
+	if err == nil {
+		return true, 0, nil
+	}
+	if lowest, ok := prunedHeight(err); ok {
+		return false, lowest, nil
+	}
+	return false, 0, err
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientHeightAwareRouting(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 1000}
+	pruned, archive := startFakeNode(t, chain), startFakeNode(t, chain)
+	pruned.setEarliest(900)
+	cli := newTestClient(t, &ClientConfig{}, pruned, archive)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cli.MonitorEndpoints(ctx, time.Minute)
+
+	require.Eventually(t, func() bool {
+		st := cli.Endpoints()
+		return st[0].EarliestHeight == 900 && st[1].EarliestHeight == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	servedPruned, servedArchive := pruned.served(), archive.served()
+	blnc, err := cli.GetAccountBalance(ctx, structs.HeightAccount{Height: 100, Account: "cosmos1account"})
+	require.NoError(t, err)
+	require.Equal(t, "100", blnc.Balances[0].Text)
+	require.Equal(t, servedPruned, pruned.served())
+	require.Equal(t, servedArchive+1, archive.served())
+
+	blnc, err = cli.GetAccountBalance(ctx, structs.HeightAccount{Height: 990, Account: "cosmos1account"})
+	require.NoError(t, err)
+	require.Equal(t, "990", blnc.Balances[0].Text)
+	require.Equal(t, servedPruned+1, pruned.served())
+	require.Equal(t, servedArchive+1, archive.served())
+}
+
+func TestClientLearnsPrunedHeightFromErrors(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 1000}
+	pruned, archive := startFakeNode(t, chain), startFakeNode(t, chain)
+	pruned.setEarliest(900)
+	cli := newTestClient(t, &ClientConfig{}, pruned, archive)
+
+	ctx := context.Background()
+	blnc, err := cli.GetAccountBalance(ctx, structs.HeightAccount{Height: 100, Account: "cosmos1account"})
+	require.NoError(t, err)
+	require.Equal(t, "100", blnc.Balances[0].Text)
+	require.Equal(t, int64(1), pruned.served())
+
+	st := cli.Endpoints()
+	require.True(t, st[0].Healthy)
+	require.Equal(t, uint64(101), st[0].EarliestStateHeight)
+	require.Zero(t, st[0].EarliestHeight)
+
+	_, err = cli.GetAccountBalance(ctx, structs.HeightAccount{Height: 50, Account: "cosmos1account"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pruned.served())
+}
+
+func TestClientStatePrunedServesBlocks(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 1000}
+	pruned, archive := startFakeNode(t, chain), startFakeNode(t, chain)
+	pruned.setStateEarliest(900)
+	cli := newTestClient(t, &ClientConfig{}, pruned, archive)
+
+	ctx := context.Background()
+	blnc, err := cli.GetAccountBalance(ctx, structs.HeightAccount{Height: 100, Account: "cosmos1account"})
+	require.NoError(t, err)
+	require.Equal(t, "100", blnc.Balances[0].Text)
+	require.Equal(t, uint64(101), cli.Endpoints()[0].EarliestStateHeight)
+
+	// blocks below pruned state are still served by the node
+	served := pruned.served()
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 100})
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), block.Height)
+	require.Equal(t, served+1, pruned.served())
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minRequestsPerSecond = 1.0
+	// decreaseInterval is the minimum time between two decreases,
+	// so burst of failures of concurrent calls is counted as one
+	decreaseInterval = time.Second
+	// latencySpikeFactor how many times slower than average call has to be to count as overload
+	latencySpikeFactor = 4
+	// defaultPriorityReserve share of the limit reserved for high priority calls
+	defaultPriorityReserve = 0.2
+	// maxPriorityReserve low priority calls are never stopped completely
+	maxPriorityReserve = 0.9
+)
+
+// Priority of the call, low priority calls (backfill) can't use the share of the limit reserved for high priority ones
+type Priority int
+
+const (
+	// PriorityHigh is priority of every call without priority set
+	PriorityHigh Priority = iota
+	PriorityLow
+)
+
+type priorityKey struct{}
+
+// WithPriority sets priority of calls made with ctx
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// WithPriorityFunc sets priority of calls made with ctx to the one returned by p at the time of the call,
+// for calls done on behalf of tasks of different priorities
+func WithPriorityFunc(ctx context.Context, p func() Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityOf returns priority of calls made with ctx
+func PriorityOf(ctx context.Context) Priority {
+	switch p := ctx.Value(priorityKey{}).(type) {
+	case Priority:
+		return p
+	case func() Priority:
+		return p()
+	}
+	return PriorityHigh
+}
+
+// priorityLimiter is rate limiter reserving share of the limit for high priority calls
+type priorityLimiter struct {
+	limiter *rate.Limiter
+	// low limits low priority calls to the share of the limit which is not reserved
+	low     *rate.Limiter
+	reserve float64
+}
+
+// newPriorityLimiter creates limiter of reqPerSecond, reserving given share of it for high priority calls
+// (0 - default, negative - none)
+func newPriorityLimiter(reqPerSecond, reserve float64) *priorityLimiter {
+	switch {
+	case reserve == 0:
+		reserve = defaultPriorityReserve
+	case reserve < 0:
+		reserve = 0
+	case reserve > maxPriorityReserve:
+		reserve = maxPriorityReserve
+	}
+	return &priorityLimiter{
+		limiter: newLimiter(reqPerSecond),
+		low:     newLimiter(reqPerSecond * (1 - reserve)),
+		reserve: reserve,
+	}
+}
+
+// Wait blocks until call is allowed
+func (pl *priorityLimiter) Wait(ctx context.Context) error {
+	if PriorityOf(ctx) == PriorityLow {
+		if err := pl.low.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return pl.limiter.Wait(ctx)
+}
+
+func (pl *priorityLimiter) setLimit(reqPerSecond float64) {
+	setLimit(pl.limiter, reqPerSecond)
+	setLimit(pl.low, reqPerSecond*(1-pl.reserve))
+}
+
+// adaptiveLimiter is rate limiter of the endpoint adapting to its responses (AIMD).
+// Limit goes up additively (by one request per second every second of successful calls)
+// and is halved when node signals overload - responds with ResourceExhausted, Unavailable or slows down.
+type adaptiveLimiter struct {
+	*priorityLimiter
+	address string
+
+	l            sync.Mutex
+	max          float64
+	current      float64
+	lastDecrease time.Time
+	// latency average latency per call, calls differ too much to compare them with each other
+	latency map[string]time.Duration
+}
+
+// newAdaptiveLimiter creates limiter of reqPerSecond, reserving given share of it for high priority calls
+// (0 - default, negative - none)
+func newAdaptiveLimiter(address string, reqPerSecond int, reserve float64) *adaptiveLimiter {
+	max := float64(reqPerSecond)
+	if max < minRequestsPerSecond {
+		max = minRequestsPerSecond
+	}
+
+	al := &adaptiveLimiter{
+		priorityLimiter: newPriorityLimiter(max, reserve),
+		address:         address,
+		max:             max,
+		current:         max,
+		latency:         make(map[string]time.Duration),
+	}
+	endpointRateLimit.WithLabels(address).Set(max)
+	return al
+}
+
+// Limit returns current limit
+func (al *adaptiveLimiter) Limit() float64 {
+	al.l.Lock()
+	defer al.l.Unlock()
+	return al.current
+}
+
+// adapt changes limit based on the result and duration of the call
+func (al *adaptiveLimiter) adapt(call string, d time.Duration, err error) {
+	al.l.Lock()
+	defer al.l.Unlock()
+
+	switch code := status.Code(err); {
+	case code == codes.ResourceExhausted || code == codes.Unavailable:
+		al.decrease()
+	case err == nil:
+		avg := al.latency[call]
+		if avg == 0 {
+			al.latency[call] = d
+		} else {
+			al.latency[call] = time.Duration(float64(avg)*(1-ewmaWeight) + float64(d)*ewmaWeight)
+		}
+
+		if avg > 0 && d > avg*latencySpikeFactor {
+			al.decrease()
+			return
+		}
+		al.increase()
+	}
+}
+
+func (al *adaptiveLimiter) increase() {
+	if al.current >= al.max {
+		return
+	}
+	al.current += 1 / al.current
+	if al.current > al.max {
+		al.current = al.max
+	}
+	al.set()
+}
+
+func (al *adaptiveLimiter) decrease() {
+	if time.Since(al.lastDecrease) < decreaseInterval {
+		return
+	}
+	al.lastDecrease = time.Now()
+	al.current /= 2
+	if al.current < minRequestsPerSecond {
+		al.current = minRequestsPerSecond
+	}
+	al.set()
+}
+
+func (al *adaptiveLimiter) set() {
+	al.setLimit(al.current)
+	endpointRateLimit.WithLabels(al.address).Set(al.current)
+}
+
+func newLimiter(limit float64) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(limit), burst(limit))
+}
+
+func setLimit(l *rate.Limiter, limit float64) {
+	l.SetLimit(rate.Limit(limit))
+	l.SetBurst(burst(limit))
+}
+
+func burst(limit float64) int {
+	if limit < 1 {
+		return 1
+	}
+	return int(limit)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	InitMetrics()
+
+	t.Run("overload halves the limit once per interval", func(t *testing.T) {
+		al := newAdaptiveLimiter("node", 100, 0)
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.ResourceExhausted, "too many requests"))
+		require.Equal(t, 50.0, al.Limit())
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+		require.Equal(t, 50.0, al.Limit())
+
+		al.lastDecrease = time.Now().Add(-decreaseInterval)
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+		require.Equal(t, 25.0, al.Limit())
+	})
+
+	t.Run("never below minimum", func(t *testing.T) {
+		al := newAdaptiveLimiter("node", 1, 0)
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.ResourceExhausted, "too many requests"))
+		require.Equal(t, minRequestsPerSecond, al.Limit())
+	})
+
+	t.Run("successes raise the limit back up to max", func(t *testing.T) {
+		al := newAdaptiveLimiter("node", 10, 0)
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.ResourceExhausted, "too many requests"))
+		require.Equal(t, 5.0, al.Limit())
+
+		// about one request per second more for every limit worth of successful calls
+		for i := 0; i < 5; i++ {
+			al.adapt("GetBlockByHeight", time.Millisecond, nil)
+		}
+		require.InDelta(t, 6.0, al.Limit(), 0.1)
+
+		for i := 0; i < 100; i++ {
+			al.adapt("GetBlockByHeight", time.Millisecond, nil)
+		}
+		require.Equal(t, 10.0, al.Limit())
+	})
+
+	t.Run("latency spike lowers the limit", func(t *testing.T) {
+		al := newAdaptiveLimiter("node", 100, 0)
+		al.adapt("GetBlockByHeight", 10*time.Millisecond, nil)
+		al.adapt("GetTxsEvent", time.Second, nil)
+		require.Equal(t, 100.0, al.Limit())
+
+		al.adapt("GetBlockByHeight", time.Second, nil)
+		require.Equal(t, 50.0, al.Limit())
+	})
+
+	t.Run("request errors don't change the limit", func(t *testing.T) {
+		al := newAdaptiveLimiter("node", 100, 0)
+		al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.NotFound, "not found"))
+		al.adapt("GetBlockByHeight", time.Millisecond, errors.New("other"))
+		require.Equal(t, 100.0, al.Limit())
+	})
+}
+
+func TestAdaptiveLimiterPriorityReserve(t *testing.T) {
+	InitMetrics()
+	al := newAdaptiveLimiter("node", 10, 0.5)
+	low := WithPriority(context.Background(), PriorityLow)
+
+	// low priority calls get only the half of the limit which is not reserved
+	for i := 0; i < 5; i++ {
+		require.NoError(t, al.Wait(low))
+	}
+	ctx, cancel := context.WithTimeout(low, 50*time.Millisecond)
+	defer cancel()
+	require.Error(t, al.Wait(ctx))
+
+	// while the reserved half is still available for high priority ones
+	for i := 0; i < 5; i++ {
+		require.NoError(t, al.Wait(context.Background()))
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Error(t, al.Wait(ctx))
+
+	// reserve follows the limit
+	al.adapt("GetBlockByHeight", time.Millisecond, status.Error(codes.ResourceExhausted, "too many requests"))
+	require.Equal(t, 5.0, al.Limit())
+	require.Equal(t, 2.5, float64(al.low.Limit()))
+
+	require.Equal(t, 0.0, newAdaptiveLimiter("node", 10, -1).reserve)
+	require.Equal(t, defaultPriorityReserve, newAdaptiveLimiter("node", 10, 0).reserve)
+
+	// tendermint rpc calls have the same reserve
+	cli := newTestClient(t, &ClientConfig{ReqPerSecond: 10, PriorityReserve: 0.5})
+	require.Equal(t, 5.0, float64(cli.rpcLimiter.low.Limit()))
+
+	// priority may change while calls are made
+	p := PriorityLow
+	shared := WithPriorityFunc(context.Background(), func() Priority { return p })
+	require.Equal(t, PriorityLow, PriorityOf(shared))
+	p = PriorityHigh
+	require.Equal(t, PriorityHigh, PriorityOf(shared))
+	require.Equal(t, PriorityHigh, PriorityOf(context.Background()))
+}
+
+func TestClientRateLimitAdapts(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{ReqPerSecond: 100, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 5 * time.Millisecond}, fn)
+	limiter := cli.pool.nodes[0].limiter
+
+	fn.failNext(codes.ResourceExhausted, 1)
+	_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+	lowered := limiter.Limit()
+	require.InDelta(t, 50.0, lowered, 0.1)
+
+	for h := uint64(1); h <= 10; h++ {
+		_, err = cli.GetBlock(context.Background(), structs.HeightHash{Height: h})
+		require.NoError(t, err)
+	}
+	require.Greater(t, limiter.Limit(), lowered)
+}
+
+func TestClientRateLimitAccountQueries(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{ReqPerSecond: 2}, fn)
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := cli.GetAccountBalance(context.Background(), structs.HeightAccount{Height: 5, Account: "cosmos1account"})
+		require.NoError(t, err)
+	}
+	// burst of 2 and then 2 requests per second
+	require.GreaterOrEqual(t, int64(time.Since(now)), int64(900*time.Millisecond))
+	require.Equal(t, int64(4), fn.served())
+}
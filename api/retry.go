@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxRetries            = 3
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// retryPolicy describes how failed calls are repeated
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func newRetryPolicy(cfg *ClientConfig) retryPolicy {
+	rp := retryPolicy{
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.RetryBaseDelay,
+		maxDelay:   cfg.RetryMaxDelay,
+	}
+
+	switch {
+	case rp.maxRetries == 0:
+		rp.maxRetries = maxRetries
+	case rp.maxRetries < 0:
+		rp.maxRetries = 0
+	}
+	if rp.baseDelay <= 0 {
+		rp.baseDelay = defaultRetryBaseDelay
+	}
+	if rp.maxDelay <= 0 {
+		rp.maxDelay = defaultRetryMaxDelay
+	}
+	return rp
+}
+
+// backoff returns jittered delay before given retry (counted from 0)
+func (rp retryPolicy) backoff(retry int) time.Duration {
+	d := rp.baseDelay << uint(retry)
+	if d > rp.maxDelay || d <= 0 {
+		d = rp.maxDelay
+	}
+	// full jitter, but never less than half of the delay
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Errors returned by the nodes are classified as:
+//   - retryable - transient state of the node, call is moved to the next node and repeated with backoff
+//   - node errors - node is not able to serve the call, call is moved to the next node
+//   - everything else (InvalidArgument, NotFound...) - request itself is wrong, returned immediately
+//
+// Unknown is a request error, cosmos-sdk returns plain errors of the queries (e.g. malformed address) with it.
+
+// isRetryable checks if error is transient and call should be repeated
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// isNodeError checks if error was caused by the node itself (and not by the request)
+func isNodeError(err error) bool {
+	if isRetryable(err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Internal:
+		return true
+	}
+	return false
+}
+
+// call runs fn against available nodes, repeating retryable failures with backoff
+// until it succeeds, retries are exhausted or context is done.
+// Fails fast with ErrCircuitOpen when nodes are considered down.
+func (c *Client) call(ctx context.Context, endpoint string, height uint64, fn func(n *node) error) (err error) {
+	if err = c.checkBreaker(); err != nil {
+		return err
+	}
+	defer func() { c.recordBreaker(ctx, err) }()
+
+	for retry := 0; ; retry++ {
+		err = c.callNodes(ctx, endpoint, height, fn)
+		if err == nil || ctx.Err() != nil || !isRetryable(err) || retry >= c.retry.maxRetries {
+			return err
+		}
+
+		wait := c.retry.backoff(retry)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			// there is no time left for another attempt
+			return err
+		}
+
+		requestRetries.WithLabels(endpoint, status.Code(err).String()).Inc()
+		c.logger.Debug("[COSMOS-API] Retrying call", zap.String("call", endpoint), zap.Int("retry", retry+1), zap.Duration("wait", wait), zap.Error(err))
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      codes.Code
+		failTimes int
+		wantCode  codes.Code
+		wantCalls int64
+	}{
+		{name: "unavailable recovered", code: codes.Unavailable, failTimes: 2, wantCode: codes.OK, wantCalls: 3},
+		{name: "resource exhausted recovered", code: codes.ResourceExhausted, failTimes: 3, wantCode: codes.OK, wantCalls: 4},
+		{name: "retries exhausted", code: codes.Unavailable, failTimes: -1, wantCode: codes.Unavailable, wantCalls: 4},
+		{name: "node error not retried", code: codes.Internal, failTimes: -1, wantCode: codes.Internal, wantCalls: 1},
+		{name: "request error not retried", code: codes.NotFound, failTimes: -1, wantCode: codes.NotFound, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &fakeChain{chainID: "test-1", height: 10}
+			fn := startFakeNode(t, chain)
+			cli := newTestClient(t, &ClientConfig{RetryBaseDelay: time.Millisecond, RetryMaxDelay: 5 * time.Millisecond}, fn)
+
+			fn.failNext(tt.code, tt.failTimes)
+			_, err := cli.GetBlock(context.Background(), structs.HeightHash{Height: 5})
+			require.Equal(t, tt.wantCode, status.Code(err))
+			require.Equal(t, tt.wantCalls, fn.served())
+		})
+	}
+}
+
+func TestClientRetryBoundedByContext(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{MaxRetries: 10, RetryBaseDelay: 50 * time.Millisecond, RetryMaxDelay: time.Second}, fn)
+	fn.setFailing(codes.Unavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	now := time.Now()
+	_, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Less(t, int64(time.Since(now)), int64(200*time.Millisecond))
+	require.Less(t, fn.served(), int64(10))
+}
@@ -17,22 +17,32 @@ type responseWithHeight struct {
 	Result types.QueryDelegatorTotalRewardsResponse `json:"result"`
 }
 
-const maxRetries = 3
-
 // GetReward fetches total rewards for delegator account
 func (c *Client) GetReward(ctx context.Context, params structs.HeightAccount) (resp structs.GetRewardResponse, err error) {
+	if cached, ok := c.cachedAccount("reward", params); ok {
+		return cached.(structs.GetRewardResponse), nil
+	}
+
 	resp.Height = params.Height
 	resp.Rewards = make(map[structs.Validator][]structs.TransactionAmount, 0)
 
-	valResp, err := c.distributionClient.DelegatorValidators(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
-		&types.QueryDelegatorValidatorsRequest{DelegatorAddress: params.Account})
+	var valResp *types.QueryDelegatorValidatorsResponse
+	err = c.call(ctx, "DelegatorValidators", params.Height, func(n *node) (err error) {
+		valResp, err = n.distributionClient.DelegatorValidators(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
+			&types.QueryDelegatorValidatorsRequest{DelegatorAddress: params.Account})
+		return err
+	})
 	if err != nil {
 		return resp, fmt.Errorf("[COSMOS-API] Error fetching validators: %w", err)
 	}
 
 	for _, val := range valResp.Validators {
-		delResp, err := c.distributionClient.DelegationRewards(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
-			&types.QueryDelegationRewardsRequest{DelegatorAddress: params.Account, ValidatorAddress: val})
+		var delResp *types.QueryDelegationRewardsResponse
+		err = c.call(ctx, "DelegationRewards", params.Height, func(n *node) (err error) {
+			delResp, err = n.distributionClient.DelegationRewards(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(params.Height, 10)),
+				&types.QueryDelegationRewardsRequest{DelegatorAddress: params.Account, ValidatorAddress: val})
+			return err
+		})
 		if err != nil {
 			return resp, fmt.Errorf("[COSMOS-API] Error fetching delegation rewards: %w", err)
 		}
@@ -52,5 +62,6 @@ func (c *Client) GetReward(ctx context.Context, params structs.HeightAccount) (r
 		resp.Rewards[structs.Validator(val)] = valRewards
 	}
 
-	return resp, err
+	c.cacheAccount("reward", params, resp)
+	return resp, nil
 }
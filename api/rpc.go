@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
+)
+
+// rpcResponse is json-rpc response of tendermint rpc
+type rpcResponse struct {
+	RPC    string          `json:"jsonrpc"`
+	Result json.RawMessage `json:"result"`
+	Error  *apiTypes.Error `json:"error"`
+}
+
+// rpcError is error returned by tendermint rpc for the request, it's not repeated on other endpoints
+type rpcError struct {
+	method string
+	err    *apiTypes.Error
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("error fetching %s: %s %s", e.method, e.err.Message, e.err.Data)
+}
+
+// rpcAddresses splits comma separated list of tendermint rpc addresses
+func rpcAddresses(addrs string) (out []string) {
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// rpcGet makes call of given method to tendermint rpc, decoding its result to res.
+// Failed call is moved to the next endpoint, when every endpoint fails it's repeated with backoff
+// until retries are exhausted or context is done.
+func (c *Client) rpcGet(ctx context.Context, method string, query url.Values, res interface{}) (err error) {
+	if len(c.rpcAddrs) == 0 {
+		return errNoTendermintRPC
+	}
+
+	for retry := 0; ; retry++ {
+		for _, addr := range c.rpcAddrs {
+			err = c.rpcGetFrom(ctx, addr, method, query, res)
+			var rerr *rpcError
+			if err == nil || ctx.Err() != nil || errors.As(err, &rerr) {
+				return err
+			}
+		}
+		if retry >= c.retry.maxRetries {
+			return err
+		}
+
+		wait := c.retry.backoff(retry)
+		requestRetries.WithLabels(method, "rpc").Inc()
+		c.logger.Debug("[COSMOS-API] Retrying rpc call", zap.String("call", method), zap.Int("retry", retry+1), zap.Duration("wait", wait), zap.Error(err))
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// rpcGetFrom makes call of given method to tendermint rpc of given address
+func (c *Client) rpcGetFrom(ctx context.Context, addr, method string, query url.Values, res interface{}) error {
+	if err := c.rpcLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+	defer cancel()
+
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	req, err := http.NewRequestWithContext(nctx, http.MethodGet, strings.TrimRight(addr, "/")+"/"+method+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		rawRequestHTTPDuration.WithLabels(method, "error").Observe(time.Since(now).Seconds())
+		return fmt.Errorf("error fetching %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	rawRequestHTTPDuration.WithLabels(method, resp.Status).Observe(time.Since(now).Seconds())
+
+	rr := &rpcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(rr); err != nil {
+		return fmt.Errorf("error decoding %s (%s): %w", method, resp.Status, err)
+	}
+	if rr.Error != nil {
+		return &rpcError{method: method, err: rr.Error}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("error fetching " + method + ": " + resp.Status)
+	}
+	if err := json.Unmarshal(rr.Result, res); err != nil {
+		return fmt.Errorf("error decoding %s: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	slashingTypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	"github.com/figment-networks/indexer-manager/structs"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// Statuses of validator signature in commit
+const (
+	SignatureSigned = "signed"
+	SignatureAbsent = "absent"
+	SignatureNil    = "nil"
+)
+
+// BlockSignatures is proposer of the block and commit of the previous block included in it
+type BlockSignatures struct {
+	// ProposerAddress consensus address (cosmosvalcons) of block proposer
+	ProposerAddress string `json:"proposer_address"`
+	// LastCommitHeight height of the block signatures are for (previous one)
+	LastCommitHeight uint64            `json:"last_commit_height"`
+	Signatures       []CommitSignature `json:"signatures"`
+	// MissedBlocksWindow signed blocks window of slashing module the missed blocks are counted in
+	// (0 when missed blocks are not counted)
+	MissedBlocksWindow uint64 `json:"missed_blocks_window,omitempty"`
+}
+
+// CommitSignature is signature of validator in commit
+type CommitSignature struct {
+	// ValidatorAddress consensus address (cosmosvalcons)
+	ValidatorAddress string    `json:"validator_address"`
+	Status           string    `json:"status"`
+	Timestamp        time.Time `json:"timestamp"`
+	// MissedBlocks number of blocks validator didn't sign within the window, as recorded by slashing module
+	MissedBlocks uint64 `json:"missed_blocks"`
+}
+
+// SignedBlock is block carrying its proposer and signatures
+type SignedBlock struct {
+	structs.Block
+	BlockSignatures
+}
+
+// GetBlockSignatures gets proposer and commit signatures of block of given height,
+// with number of blocks every validator missed in signed blocks window (when enabled).
+// Validators and missed blocks which can't be resolved (i.e. state is pruned) are left empty.
+func (c *Client) GetBlockSignatures(ctx context.Context, height uint64) (sigs BlockSignatures, err error) {
+	var ok bool
+	if sigs, ok = c.Cache.Signatures(height); !ok {
+		if sigs, ok = c.DiskCache.Signatures(height); ok {
+			c.Cache.AddSignatures(height, sigs)
+		}
+	}
+	if !ok {
+		if _, _, sigs, err = c.fetchBlock(ctx, height); err != nil {
+			return sigs, err
+		}
+	}
+	if c.complete(sigs) {
+		return sigs, nil
+	}
+
+	if !sigs.resolved() {
+		if err := c.resolveSigners(ctx, &sigs); err != nil {
+			c.logger.Debug("[COSMOS-API] Cannot resolve signers", zap.Uint64("height", height), zap.Error(err))
+		}
+	}
+	if c.cfg.MissedBlocks && sigs.MissedBlocksWindow == 0 {
+		if err := c.countMissedBlocks(ctx, height, &sigs); err != nil {
+			c.logger.Debug("[COSMOS-API] Cannot count missed blocks", zap.Uint64("height", height), zap.Error(err))
+		}
+	}
+
+	if c.complete(sigs) {
+		c.Cache.AddSignatures(height, sigs)
+		if err := c.DiskCache.PutSignatures(height, sigs); err != nil {
+			c.logger.Warn("[COSMOS-API] Error storing signatures in disk cache", zap.Uint64("height", height), zap.Error(err))
+		}
+	}
+	return sigs, nil
+}
+
+// complete checks if signatures have everything resolved, so they can be cached
+func (c *Client) complete(sigs BlockSignatures) bool {
+	if sigs.LastCommitHeight == 0 {
+		return true
+	}
+	return sigs.resolved() && (!c.cfg.MissedBlocks || sigs.MissedBlocksWindow > 0)
+}
+
+// resolved checks if every signature has validator address,
+// commit doesn't have it for validators that didn't vote (absent)
+func (sigs BlockSignatures) resolved() bool {
+	for _, s := range sigs.Signatures {
+		if s.ValidatorAddress == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveSigners fills addresses of validators that didn't vote. Signatures in commit are in order of validator set,
+// which rarely changes, so the set is fetched only when it doesn't match addresses of signatures anymore.
+func (c *Client) resolveSigners(ctx context.Context, sigs *BlockSignatures) error {
+	c.signers.l.Lock()
+	defer c.signers.l.Unlock()
+
+	if !signersMatch(c.signers.addresses, sigs.Signatures) {
+		vals, err := c.getValidators(ctx, sigs.LastCommitHeight)
+		if err != nil {
+			return err
+		}
+		c.signers.addresses = make([]string, 0, len(vals))
+		for _, v := range vals {
+			c.signers.addresses = append(c.signers.addresses, v.Address)
+		}
+		if !signersMatch(c.signers.addresses, sigs.Signatures) {
+			return fmt.Errorf("validator set at height %d doesn't match commit", sigs.LastCommitHeight)
+		}
+	}
+
+	// signatures may be shared with cache
+	resolved := make([]CommitSignature, len(sigs.Signatures))
+	copy(resolved, sigs.Signatures)
+	for i, s := range resolved {
+		if s.ValidatorAddress == "" {
+			resolved[i].ValidatorAddress = c.signers.addresses[i]
+		}
+	}
+	sigs.Signatures = resolved
+	return nil
+}
+
+// signerSet is validator set (addresses in order) of the last resolved commit
+type signerSet struct {
+	l         sync.Mutex
+	addresses []string
+}
+
+func signersMatch(signers []string, sigs []CommitSignature) bool {
+	if len(signers) != len(sigs) {
+		return false
+	}
+	for i, s := range sigs {
+		if s.ValidatorAddress != "" && s.ValidatorAddress != signers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func blockSignatures(bl *tmproto.Block) (sigs BlockSignatures) {
+	sigs.ProposerAddress = sdk.ConsAddress(bl.Header.ProposerAddress).String()
+	if bl.LastCommit == nil {
+		return sigs
+	}
+
+	sigs.LastCommitHeight = uint64(bl.LastCommit.Height)
+	for _, s := range bl.LastCommit.Signatures {
+		cs := CommitSignature{Timestamp: s.Timestamp}
+		switch s.BlockIdFlag {
+		case tmproto.BlockIDFlagCommit:
+			cs.Status = SignatureSigned
+		case tmproto.BlockIDFlagNil:
+			cs.Status = SignatureNil
+		default:
+			cs.Status = SignatureAbsent
+		}
+		if len(s.ValidatorAddress) > 0 {
+			cs.ValidatorAddress = sdk.ConsAddress(s.ValidatorAddress).String()
+		}
+		sigs.Signatures = append(sigs.Signatures, cs)
+	}
+	return sigs
+}
+
+// countMissedBlocks fills number of blocks every validator missed, as recorded by slashing module in the state
+// at the height (commit of the previous block is already counted there). Unlike counting commits seen by the worker,
+// it's the same regardless of which blocks were fetched before.
+func (c *Client) countMissedBlocks(ctx context.Context, height uint64, sigs *BlockSignatures) error {
+	hctx := metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(height, 10))
+
+	var params *slashingTypes.QueryParamsResponse
+	err := c.call(ctx, "SlashingParams", height, func(n *node) (err error) {
+		nctx, cancel := context.WithTimeout(hctx, c.cfg.TimeoutBlockCall)
+		defer cancel()
+		params, err = n.slashingClient.Params(nctx, &slashingTypes.QueryParamsRequest{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	missed := make(map[string]uint64)
+	pag := &query.PageRequest{Limit: validatorsPerPage}
+	for {
+		var res *slashingTypes.QuerySigningInfosResponse
+		err = c.call(ctx, "SigningInfos", height, func(n *node) (err error) {
+			nctx, cancel := context.WithTimeout(hctx, c.cfg.TimeoutBlockCall)
+			defer cancel()
+			res, err = n.slashingClient.SigningInfos(nctx, &slashingTypes.QuerySigningInfosRequest{Pagination: pag})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, info := range res.Info {
+			missed[info.Address] = uint64(info.MissedBlocksCounter)
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+		pag = &query.PageRequest{Key: res.Pagination.NextKey, Limit: validatorsPerPage}
+	}
+
+	// signatures may be shared with cache
+	counted := make([]CommitSignature, len(sigs.Signatures))
+	copy(counted, sigs.Signatures)
+	for i, s := range counted {
+		counted[i].MissedBlocks = missed[s.ValidatorAddress]
+	}
+	sigs.Signatures = counted
+	sigs.MissedBlocksWindow = uint64(params.Params.SignedBlocksWindow)
+	return nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	slashingTypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientGetBlockSignatures(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, validators: 4, absent: func(height int64, validator int) bool {
+		return validator == 1 && height%2 == 0
+	}}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	for h := uint64(2); h <= 9; h++ {
+		sigs, err := cli.GetBlockSignatures(ctx, h)
+		require.NoError(t, err)
+
+		require.Equal(t, chain.validatorConsAddress(int(h)%4), sigs.ProposerAddress)
+		require.Equal(t, h-1, sigs.LastCommitHeight)
+		require.Len(t, sigs.Signatures, 4)
+		for i, s := range sigs.Signatures {
+			require.Equal(t, chain.validatorConsAddress(i), s.ValidatorAddress)
+			if chain.absent(int64(h-1), i) {
+				require.Equal(t, SignatureAbsent, s.Status)
+			} else {
+				require.Equal(t, SignatureSigned, s.Status)
+			}
+		}
+	}
+
+	// validator set is fetched only for the first commit with absent validator
+	require.Equal(t, int64(9), fn.served())
+
+	// block fetched for GetBlock carries signatures
+	_, err := cli.GetBlock(ctx, structs.HeightHash{Height: 15})
+	require.NoError(t, err)
+	served := fn.served()
+	sigs, err := cli.GetBlockSignatures(ctx, 15)
+	require.NoError(t, err)
+	require.Equal(t, served, fn.served())
+	require.Equal(t, uint64(14), sigs.LastCommitHeight)
+	require.Empty(t, sigs.MissedBlocksWindow)
+}
+
+func TestClientGetBlockSignaturesMissedBlocks(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, validators: 4, signedBlocksWindow: 4, absent: func(height int64, validator int) bool {
+		return validator == 1 && height%2 == 0
+	}}
+	fn := startFakeNode(t, chain)
+	ctx := context.Background()
+
+	// missed blocks are the same in whatever order blocks are fetched
+	for _, heights := range [][]uint64{{9, 15}, {15, 9}} {
+		cli := newTestClient(t, &ClientConfig{MissedBlocks: true}, fn)
+		for _, h := range heights {
+			sigs, err := cli.GetBlockSignatures(ctx, h)
+			require.NoError(t, err)
+			require.Equal(t, uint64(4), sigs.MissedBlocksWindow)
+			require.Equal(t, uint64(0), sigs.Signatures[0].MissedBlocks)
+			require.Equal(t, uint64(2), sigs.Signatures[1].MissedBlocks)
+		}
+	}
+
+	// counted signatures are cached
+	cli := newTestClient(t, &ClientConfig{MissedBlocks: true}, fn)
+	sigs, err := cli.GetBlockSignatures(ctx, 9)
+	require.NoError(t, err)
+	served := fn.served()
+	cached, err := cli.GetBlockSignatures(ctx, 9)
+	require.NoError(t, err)
+	require.Equal(t, served, fn.served())
+	require.Equal(t, sigs, cached)
+}
+
+func TestClientGetBlockSignaturesUnresolved(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, validators: 4, absent: func(height int64, validator int) bool {
+		return validator == 1
+	}}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{MissedBlocks: true}, fn)
+	ctx := context.Background()
+
+	_, err := cli.GetBlock(ctx, structs.HeightHash{Height: 5})
+	require.NoError(t, err)
+
+	// state is not available, signatures are returned without validators that didn't vote and missed blocks
+	fn.setFailing(codes.InvalidArgument)
+	sigs, err := cli.GetBlockSignatures(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, sigs.Signatures, 4)
+	require.Empty(t, sigs.Signatures[1].ValidatorAddress)
+	require.Equal(t, chain.validatorConsAddress(2), sigs.Signatures[2].ValidatorAddress)
+	require.Empty(t, sigs.MissedBlocksWindow)
+
+	// incomplete signatures are not cached
+	fn.setFailing(codes.OK)
+	sigs, err = cli.GetBlockSignatures(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, chain.validatorConsAddress(1), sigs.Signatures[1].ValidatorAddress)
+	require.Equal(t, uint64(4), sigs.Signatures[1].MissedBlocks)
+	require.Equal(t, uint64(defaultSignedBlocksWindow), sigs.MissedBlocksWindow)
+}
+
+// fakeSlashingService serves signing infos of validators of the chain in pages of two
+type fakeSlashingService struct {
+	slashingTypes.UnimplementedQueryServer
+	fn *fakeNode
+}
+
+const defaultSignedBlocksWindow = 100
+
+func (fss *fakeSlashingService) window() int64 {
+	if fss.fn.chain.signedBlocksWindow == 0 {
+		return defaultSignedBlocksWindow
+	}
+	return fss.fn.chain.signedBlocksWindow
+}
+
+func (fss *fakeSlashingService) Params(ctx context.Context, req *slashingTypes.QueryParamsRequest) (*slashingTypes.QueryParamsResponse, error) {
+	if err := fss.fn.request(); err != nil {
+		return nil, err
+	}
+	return &slashingTypes.QueryParamsResponse{Params: slashingTypes.Params{SignedBlocksWindow: fss.window()}}, nil
+}
+
+// SigningInfos counts commits validator missed in the window, up to commit of the previous block
+func (fss *fakeSlashingService) SigningInfos(ctx context.Context, req *slashingTypes.QuerySigningInfosRequest) (*slashingTypes.QuerySigningInfosResponse, error) {
+	if err := fss.fn.request(); err != nil {
+		return nil, err
+	}
+
+	var height int64
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if h := md.Get(grpctypes.GRPCBlockHeightHeader); len(h) > 0 {
+			height, _ = strconv.ParseInt(h[0], 10, 64)
+		}
+	}
+	if fss.fn.statePruned(height) {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to load state at height %d; version does not exist (latest height: %d)", height, fss.fn.chain.height)
+	}
+
+	start := 0
+	if req.Pagination != nil && len(req.Pagination.Key) > 0 {
+		start, _ = strconv.Atoi(string(req.Pagination.Key))
+	}
+
+	res := &slashingTypes.QuerySigningInfosResponse{Pagination: &query.PageResponse{}}
+	for i := start; i < start+2 && i < fss.fn.chain.validators; i++ {
+		info := slashingTypes.ValidatorSigningInfo{Address: fss.fn.chain.validatorConsAddress(i)}
+		for h := height - 1; h > 0 && h > height-1-fss.window(); h-- {
+			if fss.fn.chain.absent != nil && fss.fn.chain.absent(h, i) {
+				info.MissedBlocksCounter++
+			}
+		}
+		res.Info = append(res.Info, info)
+	}
+	if start+2 < fss.fn.chain.validators {
+		res.Pagination.NextKey = []byte(strconv.Itoa(start + 2))
+	}
+	return res, nil
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	apiTypes "github.com/figment-networks/cosmos-worker/api/types"
+)
+
+const (
+	newBlockQuery = "tm.event='NewBlock'"
+	// tendermint pings websocket clients every 27s, connection without any message for longer is considered dead
+	wsReadTimeout  = time.Minute
+	wsWriteTimeout = 10 * time.Second
+)
+
+var errNoTendermintRPC = errors.New("tendermint rpc address is not set")
+
+// wsRequest is json-rpc request sent over websocket
+type wsRequest struct {
+	RPC    string            `json:"jsonrpc"`
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params map[string]string `json:"params"`
+}
+
+// SubscribeNewBlocks subscribes to NewBlock events of tendermint rpc websocket and sends heights of new blocks to out
+// until ctx is done. Heights are sent in order without gaps, starting from given height (0 - from the first received one).
+// When connection is lost it's reestablished with backoff (to the next tendermint rpc address),
+// heights produced in the meantime are sent as soon as the next block is received.
+func (c *Client) SubscribeNewBlocks(ctx context.Context, from uint64, out chan<- uint64) error {
+	if len(c.rpcAddrs) == 0 {
+		return errNoTendermintRPC
+	}
+
+	next := from
+	retry := 0
+	for conn := 0; ; conn++ {
+		received, err := c.subscribeNewBlocks(ctx, c.rpcAddrs[conn%len(c.rpcAddrs)], &next, out)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if received {
+			retry = 0
+		}
+
+		newBlockSubscriptionReconnects.WithLabels().Inc()
+		delay := c.retry.backoff(retry)
+		c.logger.Warn("[COSMOS-API] New block subscription lost, reconnecting", zap.Error(err), zap.Uint64("next_height", next), zap.Duration("delay", delay))
+		retry++
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// subscribeNewBlocks runs single websocket connection, until it fails. Returns if any block was received.
+func (c *Client) subscribeNewBlocks(ctx context.Context, rpcAddr string, next *uint64, out chan<- uint64) (received bool, err error) {
+	addr := wsAddress(rpcAddr)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, addr, nil)
+	if err != nil {
+		return false, fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// close connection to interrupt blocked read when ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(wsWriteTimeout))
+	})
+
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(wsRequest{RPC: "2.0", ID: 1, Method: "subscribe", Params: map[string]string{"query": newBlockQuery}}); err != nil {
+		return false, fmt.Errorf("error subscribing to new blocks: %w", err)
+	}
+	c.logger.Info("[COSMOS-API] Subscribed to new blocks", zap.String("address", addr), zap.Uint64("next_height", *next))
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		ev := &apiTypes.EventNewBlockResponse{}
+		if err := conn.ReadJSON(ev); err != nil {
+			return received, fmt.Errorf("error reading new block event: %w", err)
+		}
+		if ev.Error != nil {
+			return received, fmt.Errorf("error subscribing to new blocks: %s %s", ev.Error.Message, ev.Error.Data)
+		}
+		if ev.Result.Data.Type == "" { // confirmation of subscription
+			continue
+		}
+
+		height, err := strconv.ParseUint(ev.Result.Data.Value.Block.Header.Height, 10, 64)
+		if err != nil {
+			return received, fmt.Errorf("error parsing height of new block: %w", err)
+		}
+		received = true
+
+		if *next == 0 {
+			*next = height
+		}
+		for ; *next <= height; *next++ {
+			select {
+			case out <- *next:
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+		}
+	}
+}
+
+// wsAddress is address of websocket endpoint of tendermint rpc
+func wsAddress(addr string) string {
+	switch {
+	case strings.HasPrefix(addr, "https://"):
+		addr = "wss://" + strings.TrimPrefix(addr, "https://")
+	case strings.HasPrefix(addr, "http://"):
+		addr = "ws://" + strings.TrimPrefix(addr, "http://")
+	case !strings.Contains(addr, "://"):
+		addr = "ws://" + addr
+	}
+	return strings.TrimRight(addr, "/") + "/websocket"
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNewBlocks serves tendermint rpc websocket, every connection sends NewBlock events of next batch of heights
+// and then it's dropped (the last one is kept open)
+func fakeNewBlocks(t *testing.T, batches ...[]uint64) (srv *httptest.Server, conns *int32) {
+	t.Helper()
+
+	conns = new(int32)
+	upgrader := websocket.Upgrader{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		n := int(atomic.AddInt32(conns, 1)) - 1
+		req := wsRequest{}
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "subscribe" || req.Params["query"] != newBlockQuery {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Invalid Request","data":"unexpected request"}}`))
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+
+		if n >= len(batches) {
+			conn.ReadMessage() // wait for client to disconnect
+			return
+		}
+		for _, h := range batches[n] {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+				`{"jsonrpc":"2.0","id":1,"result":{"query":"tm.event='NewBlock'","data":{"type":"tendermint/event/NewBlock","value":{"block":{"header":{"chain_id":"test-1","height":"%d"}}}}}}`, h)))
+		}
+		if n == len(batches)-1 {
+			conn.ReadMessage()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, conns
+}
+
+func TestClientSubscribeNewBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    uint64
+		batches [][]uint64
+		want    []uint64
+	}{
+		{
+			name:    "single connection",
+			batches: [][]uint64{{5, 6, 7}},
+			want:    []uint64{5, 6, 7},
+		},
+		{
+			name:    "heights skipped while disconnected",
+			batches: [][]uint64{{5, 6}, {9, 10}, {}, {12}},
+			want:    []uint64{5, 6, 7, 8, 9, 10, 11, 12},
+		},
+		{
+			name:    "resume from height",
+			from:    3,
+			batches: [][]uint64{{5}, {6}},
+			want:    []uint64{3, 4, 5, 6},
+		},
+		{
+			name:    "repeated heights",
+			batches: [][]uint64{{5, 6}, {6, 7}},
+			want:    []uint64{5, 6, 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, conns := fakeNewBlocks(t, tt.batches...)
+			cli := newTestClient(t, &ClientConfig{
+				TendermintRPCAddr: srv.URL,
+				RetryBaseDelay:    time.Millisecond,
+				RetryMaxDelay:     10 * time.Millisecond,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			out := make(chan uint64)
+			errs := make(chan error, 1)
+			go func() { errs <- cli.SubscribeNewBlocks(ctx, tt.from, out) }()
+
+			var got []uint64
+			for len(got) < len(tt.want) {
+				select {
+				case h := <-out:
+					got = append(got, h)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timeout waiting for heights, got %v", got)
+				}
+			}
+			require.Equal(t, tt.want, got)
+			require.Equal(t, int32(len(tt.batches)), atomic.LoadInt32(conns))
+
+			cancel()
+			require.ErrorIs(t, <-errs, context.Canceled)
+		})
+	}
+}
+
+func TestClientSubscribeNewBlocksWithoutRPC(t *testing.T) {
+	cli := newTestClient(t, &ClientConfig{})
+	require.Equal(t, errNoTendermintRPC, cli.SubscribeNewBlocks(context.Background(), 0, make(chan uint64)))
+}
+
+func TestWSAddress(t *testing.T) {
+	for addr, want := range map[string]string{
+		"127.0.0.1:26657":          "ws://127.0.0.1:26657/websocket",
+		"http://127.0.0.1:26657/":  "ws://127.0.0.1:26657/websocket",
+		"https://rpc.example.com":  "wss://rpc.example.com/websocket",
+		"ws://rpc.example.com:443": "ws://rpc.example.com:443/websocket",
+	} {
+		require.Equal(t, want, wsAddress(addr), addr)
+	}
+}
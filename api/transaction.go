@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/figment-networks/cosmos-worker/api/mapper"
@@ -17,71 +20,205 @@ import (
 	"github.com/cosmos/cosmos-sdk/types/query"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	errUnknownMessageType = fmt.Errorf("unknown message type")
 )
 
+// defaultTxPageConcurrency is default number of pages of transactions of one block fetched at the same time
+const defaultTxPageConcurrency = 4
+
+// defaultTxsPerPage is number of transactions fetched in one page when none is given
+const defaultTxsPerPage = 100
+
 var curencyRegex = regexp.MustCompile("([0-9\\.\\,\\-\\s]+)([^0-9\\s]+)$")
 
 // SearchTx is making search api call
 func (c *Client) SearchTx(ctx context.Context, r structs.HeightHash, block structs.Block, perPage uint64) (txs []structs.Transaction, err error) {
-	pag := &query.PageRequest{
-		CountTotal: true,
-		Limit:      perPage,
-	}
-
 	numberOfItemsInBlock.Add(float64(block.NumberOfTransactions))
-	var page = uint64(1)
-	for {
-		pag.Offset = (perPage * page) - perPage
-		now := time.Now()
 
-		if err = c.rateLimiterGRPC.Wait(ctx); err != nil {
+	grpcRes, ok := c.DiskCache.TxsEvent(r.Height)
+	if !ok && c.cfg.TxSource == TxSourceBlock && len(c.rpcAddrs) > 0 {
+		if grpcRes, err = c.txsFromBlock(ctx, r.Height); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			txSourceFallbacks.WithLabels().Inc()
+			c.logger.Warn("[COSMOS-API] Error decoding transactions from block, falling back to GetTxsEvent", zap.Uint64("height", r.Height), zap.Error(err))
+		}
+	}
+	if !ok {
+		if grpcRes == nil {
+			if grpcRes, err = c.getTxsEvent(ctx, r.Height, block.NumberOfTransactions, perPage); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.DiskCache.PutTxsEvent(r.Height, grpcRes); err != nil {
+			c.logger.Warn("[COSMOS-API] Error storing transactions in disk cache", zap.Uint64("height", r.Height), zap.Error(err))
+		}
+	}
+	numberOfItemsTransactions.Add(float64(len(grpcRes.Txs)))
+
+	for i, trans := range grpcRes.Txs {
+		resp := grpcRes.TxResponses[i]
+		n := time.Now()
+		tx, err := rawToTransaction(ctx, trans, resp, c.logger)
+		if err != nil {
 			return nil, err
 		}
+		conversionDuration.WithLabels(resp.Tx.TypeUrl).Observe(time.Since(n).Seconds())
+		tx.BlockHash = block.Hash
+		tx.ChainID = block.ChainID
+		tx.Time = block.Time
+		txs = append(txs, tx)
+	}
+
+	c.logger.Debug("[COSMOS-API] Sending requests ", zap.Int("number", len(txs)))
+	return txs, nil
+}
 
+// ErrTxNotFound is returned when node doesn't know transaction of given hash
+var ErrTxNotFound = status.Error(codes.NotFound, "transaction not found")
+
+// GetTransaction gets transaction of given (hex encoded) hash with details of the block it's included in
+func (c *Client) GetTransaction(ctx context.Context, hash string) (trans structs.Transaction, err error) {
+	if h, err := hex.DecodeString(hash); err != nil || len(h) != sha256.Size {
+		return trans, status.Errorf(codes.InvalidArgument, "invalid transaction hash %q", hash)
+	}
+
+	var res *tx.GetTxResponse
+	err = c.call(ctx, "GetTx", 0, func(n *node) (err error) {
 		nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutSearchTxCall)
-		grpcRes, err := c.txServiceClient.GetTxsEvent(nctx, &tx.GetTxsEventRequest{
-			Events:     []string{"tx.height=" + strconv.FormatUint(r.Height, 10)},
-			Pagination: pag,
-		}, grpc.WaitForReady(true))
-		cancel()
+		defer cancel()
+		res, err = n.txServiceClient.GetTx(nctx, &tx.GetTxRequest{Hash: hash}, c.pool.waitForReady())
+		if err != nil && strings.Contains(status.Convert(err).Message(), "not found") {
+			// unknown hash is not failure of the node
+			return ErrTxNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return trans, err
+	}
+
+	n := time.Now()
+	trans, err = rawToTransaction(ctx, res.Tx, res.TxResponse, c.logger)
+	if err != nil {
+		return trans, err
+	}
+	conversionDuration.WithLabels(res.TxResponse.Tx.GetTypeUrl()).Observe(time.Since(n).Seconds())
+
+	block, err := c.GetBlock(ctx, structs.HeightHash{Height: uint64(res.TxResponse.Height)})
+	if err != nil {
+		return trans, fmt.Errorf("error fetching block of transaction: %w", err)
+	}
+	trans.BlockHash = block.Hash
+	trans.ChainID = block.ChainID
+	trans.Time = block.Time
+
+	return trans, nil
+}
+
+// getTxsEvent gets all pages of transactions of given height, merged into one response.
+// Pages of expected number of transactions (total) are fetched concurrently, if node has indexed
+// more transactions than expected the rest is fetched afterwards.
+func (c *Client) getTxsEvent(ctx context.Context, height, total, perPage uint64) (res *tx.GetTxsEventResponse, err error) {
+	if perPage == 0 {
+		perPage = defaultTxsPerPage
+	}
+	pages := (total + perPage - 1) / perPage
+	if pages == 0 {
+		pages = 1
+	}
 
-		c.logger.Debug("[COSMOS-API] Request Time (/tx_search)", zap.Duration("duration", time.Now().Sub(now)))
+	res = &tx.GetTxsEventResponse{}
+	for page := uint64(0); ; {
+		now := time.Now()
+		resps, err := c.getTxsEventPages(ctx, height, perPage, page, pages)
+		c.logger.Debug("[COSMOS-API] Request Time (/tx_search)", zap.Duration("duration", time.Now().Sub(now)), zap.Uint64("pages", pages))
 		if err != nil {
-			rawRequestGRPCDuration.WithLabels("GetTxsEvent", "error").Observe(time.Since(now).Seconds())
 			return nil, err
 		}
-		rawRequestGRPCDuration.WithLabels("GetTxsEvent", "ok").Observe(time.Since(now).Seconds())
-		numberOfItemsTransactions.Add(float64(len(grpcRes.Txs)))
 
-		for i, trans := range grpcRes.Txs {
-			resp := grpcRes.TxResponses[i]
-			n := time.Now()
-			tx, err := rawToTransaction(ctx, trans, resp, c.logger)
-			if err != nil {
-				return nil, err
-			}
-			conversionDuration.WithLabels(resp.Tx.TypeUrl).Observe(time.Since(n).Seconds())
-			tx.BlockHash = block.Hash
-			tx.ChainID = block.ChainID
-			tx.Time = block.Time
-			txs = append(txs, tx)
+		var fetched int
+		for _, grpcRes := range resps {
+			res.Txs = append(res.Txs, grpcRes.Txs...)
+			res.TxResponses = append(res.TxResponses, grpcRes.TxResponses...)
+			fetched += len(grpcRes.Txs)
 		}
 
-		if grpcRes.Pagination.GetTotal() <= uint64(len(txs)) {
+		indexed := resps[0].Pagination.GetTotal()
+		if indexed <= uint64(len(res.Txs)) || fetched == 0 {
 			break
 		}
+		page += pages
+		pages = (indexed - uint64(len(res.Txs)) + perPage - 1) / perPage
+	}
 
-		page++
+	return res, nil
+}
 
+func (c *Client) txPageConcurrency() int {
+	if c.cfg.TxPageConcurrency > 0 {
+		return c.cfg.TxPageConcurrency
 	}
+	return defaultTxPageConcurrency
+}
 
-	c.logger.Debug("[COSMOS-API] Sending requests ", zap.Int("number", len(txs)))
-	return txs, nil
+// getTxsEventPages gets n pages starting from (zero based) page first concurrently, returning them in order.
+// The first error cancels the rest of requests and is returned.
+func (c *Client) getTxsEventPages(ctx context.Context, height, perPage, first, n uint64) ([]*tx.GetTxsEventResponse, error) {
+	pctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	resps := make([]*tx.GetTxsEventResponse, n)
+	sem := make(chan struct{}, c.txPageConcurrency())
+	wg := &sync.WaitGroup{}
+	for i := uint64(0); i < n && pctx.Err() == nil; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i uint64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pag := &query.PageRequest{
+				CountTotal: i == 0,
+				Offset:     (first + i) * perPage,
+				Limit:      perPage,
+			}
+			err := c.call(pctx, "GetTxsEvent", height, func(nd *node) (err error) {
+				nctx, cancel := context.WithTimeout(pctx, c.cfg.TimeoutSearchTxCall)
+				defer cancel()
+				resps[i], err = nd.txServiceClient.GetTxsEvent(nctx, &tx.GetTxsEventRequest{
+					Events:     []string{"tx.height=" + strconv.FormatUint(height, 10)},
+					Pagination: pag,
+				}, c.pool.waitForReady())
+				return err
+			})
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return resps, nil
 }
 
 // transform raw data from cosmos into transaction format with augmentation from blocks
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientSearchTxPages(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, txsPerBlock: 950}
+	fn := startFakeNode(t, chain)
+	fn.txsDelay = 20 * time.Millisecond
+	cli := newTestClient(t, &ClientConfig{MaxRetries: -1, TxPageConcurrency: 3}, fn)
+	ctx := context.Background()
+
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint64(950), block.NumberOfTransactions)
+
+	served := fn.served()
+	txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 10}, block, 100)
+	require.NoError(t, err)
+	require.Len(t, txs, 950)
+	for i, tx := range txs {
+		require.Equal(t, fmt.Sprintf("tx-10-%d", i), tx.Memo)
+	}
+	require.Equal(t, served+10, fn.served())
+	require.Equal(t, int64(3), fn.txsMaxInflight)
+
+	t.Run("more transactions indexed than expected", func(t *testing.T) {
+		block := block
+		block.Height = 11
+		block.NumberOfTransactions = 250
+
+		txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 11}, block, 100)
+		require.NoError(t, err)
+		require.Len(t, txs, 950)
+		require.Equal(t, "tx-11-949", txs[949].Memo)
+	})
+
+	t.Run("default page size", func(t *testing.T) {
+		block := block
+		block.Height = 13
+
+		served := fn.served()
+		txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 13}, block, 0)
+		require.NoError(t, err)
+		require.Len(t, txs, 950)
+		require.Equal(t, served+10, fn.served())
+	})
+
+	t.Run("failed page", func(t *testing.T) {
+		fn.failNext(codes.InvalidArgument, 1)
+		_, err := cli.SearchTx(ctx, structs.HeightHash{Height: 12}, block, 100)
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestClientGetTransaction(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10, txsPerBlock: 3}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{BreakerThreshold: 1}, fn)
+	ctx := context.Background()
+
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 7})
+	require.NoError(t, err)
+	txs, err := cli.SearchTx(ctx, structs.HeightHash{Height: 7}, block, 100)
+	require.NoError(t, err)
+
+	tx, err := cli.GetTransaction(ctx, txs[2].Hash)
+	require.NoError(t, err)
+	require.Equal(t, txs[2], tx)
+	require.Equal(t, block.Hash, tx.BlockHash)
+	require.Equal(t, "test-1", tx.ChainID)
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := cli.GetTransaction(ctx, strings.Repeat("AB", 32))
+		require.ErrorIs(t, err, ErrTxNotFound)
+		require.Equal(t, BreakerClosed, cli.BreakerState())
+		require.True(t, cli.Endpoints()[0].Healthy)
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		served := fn.served()
+		_, err := cli.GetTransaction(ctx, "not a hash")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, served, fn.served())
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/figment-networks/indexer-manager/structs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TxQuery is search of transactions by events emitted by them,
+// e.g. message.sender=cosmos1..., transfer.recipient=cosmos1...
+type TxQuery struct {
+	Events []string `json:"events"`
+	// StartHeight and EndHeight bound heights of transactions (0 - not bounded)
+	StartHeight uint64 `json:"start_height"`
+	EndHeight   uint64 `json:"end_height"`
+}
+
+// Validate checks if query can be sent to node
+func (q TxQuery) Validate() error {
+	if len(q.Events) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one event is required")
+	}
+	for _, ev := range q.Events {
+		if strings.Count(ev, "=") != 1 || strings.HasPrefix(ev, "=") || strings.HasSuffix(ev, "=") {
+			return status.Errorf(codes.InvalidArgument, "invalid event %q, it should be in form {eventType}.{eventAttribute}={value}", ev)
+		}
+	}
+	if q.EndHeight > 0 && q.StartHeight > q.EndHeight {
+		return status.Error(codes.InvalidArgument, "start height is greater than end height")
+	}
+	return nil
+}
+
+func (q TxQuery) events() []string {
+	events := append([]string{}, q.Events...)
+	if q.StartHeight > 0 {
+		events = append(events, "tx.height>="+strconv.FormatUint(q.StartHeight, 10))
+	}
+	if q.EndHeight > 0 {
+		events = append(events, "tx.height<="+strconv.FormatUint(q.EndHeight, 10))
+	}
+	return events
+}
+
+// SearchTxsByEvents gets (zero based) page of transactions matching the query in ascending order of heights,
+// with total number of matching transactions
+func (c *Client) SearchTxsByEvents(ctx context.Context, q TxQuery, page, perPage uint64) (txs []structs.Transaction, total uint64, err error) {
+	if err := q.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	var res *tx.GetTxsEventResponse
+	err = c.call(ctx, "GetTxsEvent", 0, func(n *node) (err error) {
+		nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutSearchTxCall)
+		defer cancel()
+		res, err = n.txServiceClient.GetTxsEvent(nctx, &tx.GetTxsEventRequest{
+			Events:     q.events(),
+			Pagination: &query.PageRequest{Offset: page * perPage, Limit: perPage, CountTotal: true},
+			OrderBy:    tx.OrderBy_ORDER_BY_ASC,
+		}, c.pool.waitForReady())
+		if err != nil && strings.Contains(status.Convert(err).Message(), "failed to parse query") {
+			// query is wrong, not the node
+			return status.Error(codes.InvalidArgument, status.Convert(err).Message())
+		}
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blocks := map[int64]structs.Block{}
+	for i, trans := range res.Txs {
+		resp := res.TxResponses[i]
+		block, ok := blocks[resp.Height]
+		if !ok {
+			if block, err = c.GetBlock(ctx, structs.HeightHash{Height: uint64(resp.Height)}); err != nil {
+				return nil, 0, fmt.Errorf("error fetching block of transaction: %w", err)
+			}
+			blocks[resp.Height] = block
+		}
+
+		n := time.Now()
+		t, err := rawToTransaction(ctx, trans, resp, c.logger)
+		if err != nil {
+			return nil, 0, err
+		}
+		conversionDuration.WithLabels(resp.Tx.GetTypeUrl()).Observe(time.Since(n).Seconds())
+		t.BlockHash = block.Hash
+		t.ChainID = block.ChainID
+		t.Time = block.Time
+		txs = append(txs, t)
+	}
+
+	c.logger.Debug("[COSMOS-API] Found transactions", zap.Strings("events", q.Events), zap.Uint64("page", page), zap.Int("number", len(txs)))
+	return txs, res.Pagination.GetTotal(), nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientSearchTxsByEvents(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 20, txsPerBlock: 3}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	q := TxQuery{Events: []string{"message.sender=cosmos1sender"}, StartHeight: 5, EndHeight: 8}
+	txs, total, err := cli.SearchTxsByEvents(ctx, q, 0, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), total)
+	require.Len(t, txs, 5)
+	require.Equal(t, "tx-5-0", txs[0].Memo)
+	require.Equal(t, "tx-6-1", txs[4].Memo)
+
+	block, err := cli.GetBlock(ctx, structs.HeightHash{Height: 6})
+	require.NoError(t, err)
+	require.Equal(t, block.Hash, txs[4].BlockHash)
+	require.Equal(t, block.Time, txs[4].Time)
+	require.Equal(t, "test-1", txs[4].ChainID)
+
+	txs, _, err = cli.SearchTxsByEvents(ctx, q, 2, 5)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, "tx-8-2", txs[1].Memo)
+
+	t.Run("no match", func(t *testing.T) {
+		txs, total, err := cli.SearchTxsByEvents(ctx, TxQuery{Events: []string{"message.sender=cosmos1other"}}, 0, 5)
+		require.NoError(t, err)
+		require.Zero(t, total)
+		require.Empty(t, txs)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		for _, q := range []TxQuery{
+			{},
+			{Events: []string{"message.sender"}},
+			{Events: []string{"message.sender=a=b"}},
+			{Events: []string{"message.sender=cosmos1sender"}, StartHeight: 10, EndHeight: 5},
+		} {
+			served := fn.served()
+			_, _, err := cli.SearchTxsByEvents(ctx, q, 0, 5)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), fmt.Sprint(q))
+			require.Equal(t, served, fn.served())
+		}
+
+		// rejected by node
+		_, _, err := cli.SearchTxsByEvents(ctx, TxQuery{Events: []string{"message.sender='x"}}, 0, 5)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.True(t, cli.Endpoints()[0].Healthy)
+	})
+}
@@ -99,3 +99,60 @@ type GetBlockchainResponse struct {
 	Result ResultBlockchain `json:"result"`
 	Error  Error            `json:"error"`
 }
+
+// ResultBlockResults is result of fetching block results
+type ResultBlockResults struct {
+	Height           string       `json:"height"`
+	TxsResults       []ResponseTx `json:"txs_results"`
+	BeginBlockEvents []ABCIEvent  `json:"begin_block_events"`
+	EndBlockEvents   []ABCIEvent  `json:"end_block_events"`
+}
+
+// ResponseTx is result of transaction execution (DeliverTx)
+type ResponseTx struct {
+	Code      uint32      `json:"code"`
+	Data      []byte      `json:"data"`
+	Log       string      `json:"log"`
+	Info      string      `json:"info"`
+	GasWanted int64       `json:"gas_wanted,string"`
+	GasUsed   int64       `json:"gas_used,string"`
+	Events    []ABCIEvent `json:"events"`
+	Codespace string      `json:"codespace"`
+}
+
+// ABCIEvent is event emitted by application
+type ABCIEvent struct {
+	Type       string               `json:"type"`
+	Attributes []ABCIEventAttribute `json:"attributes"`
+}
+
+// ABCIEventAttribute is attribute of event (base64 encoded key and value)
+type ABCIEventAttribute struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// GetBlockResultsResponse cosmos response from block_results
+type GetBlockResultsResponse struct {
+	RPC    string             `json:"jsonrpc"`
+	Result ResultBlockResults `json:"result"`
+	Error  *Error             `json:"error"`
+}
+
+// EventNewBlockResponse is message received on tendermint rpc websocket subscription to NewBlock events
+// (only data needed to get the block is decoded)
+type EventNewBlockResponse struct {
+	RPC    string `json:"jsonrpc"`
+	Result struct {
+		Query string `json:"query"`
+		Data  struct {
+			Type  string `json:"type"`
+			Value struct {
+				Block struct {
+					Header BlockHeader `json:"header"`
+				} `json:"block"`
+			} `json:"value"`
+		} `json:"data"`
+	} `json:"result"`
+	Error *Error `json:"error"`
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codec_types "github.com/cosmos/cosmos-sdk/codec/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// validatorsPerPage is the maximum number of validators tendermint returns at once
+const validatorsPerPage = 100
+
+// errNoMorePages is returned for page after the last one, it's not failure of the node
+var errNoMorePages = status.Error(codes.OutOfRange, "no more pages")
+
+// ValidatorSet is set of validators active at height
+type ValidatorSet struct {
+	Height     uint64      `json:"height"`
+	Validators []Validator `json:"validators"`
+}
+
+// Validator is member of the active validator set
+type Validator struct {
+	// Address is consensus address (cosmosvalcons)
+	Address          string `json:"address"`
+	VotingPower      int64  `json:"voting_power"`
+	ProposerPriority int64  `json:"proposer_priority"`
+	// OperatorAddress (cosmosvaloper) and Moniker are taken from staking module if available at height
+	OperatorAddress string `json:"operator_address,omitempty"`
+	Moniker         string `json:"moniker,omitempty"`
+
+	pubKey *codec_types.Any
+}
+
+// GetValidatorSet fetches validator set active at given height
+func (c *Client) GetValidatorSet(ctx context.Context, height uint64) (vs ValidatorSet, err error) {
+	vs.Height = height
+	if vs.Validators, err = c.getValidators(ctx, height); err != nil {
+		return vs, fmt.Errorf("[COSMOS-API] Error fetching validator set: %w", err)
+	}
+
+	c.resolveOperators(ctx, &vs)
+	return vs, nil
+}
+
+// getValidators fetches all pages of validator set at given height, in tendermint order
+func (c *Client) getValidators(ctx context.Context, height uint64) (vals []Validator, err error) {
+	pag := &query.PageRequest{Limit: validatorsPerPage}
+	for {
+		var res *tmservice.GetValidatorSetByHeightResponse
+		err = c.call(ctx, "GetValidatorSetByHeight", height, func(n *node) (err error) {
+			nctx, cancel := context.WithTimeout(ctx, c.cfg.TimeoutBlockCall)
+			defer cancel()
+			res, err = n.tmServiceClient.GetValidatorSetByHeight(nctx, &tmservice.GetValidatorSetByHeightRequest{Height: int64(height), Pagination: pag}, c.pool.waitForReady())
+			if err != nil && pag.Offset > 0 && strings.Contains(status.Convert(err).Message(), "page should be within") {
+				// tendermint doesn't report total, so there is no way to know that previous page was the last full one
+				return errNoMorePages
+			}
+			return err
+		})
+		if errors.Is(err, errNoMorePages) {
+			return vals, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range res.Validators {
+			vals = append(vals, Validator{
+				Address:          v.Address,
+				VotingPower:      v.VotingPower,
+				ProposerPriority: v.ProposerPriority,
+				pubKey:           v.PubKey,
+			})
+		}
+
+		if len(res.Validators) < validatorsPerPage || (res.Pagination != nil && res.Pagination.Total > 0 && uint64(len(vals)) >= res.Pagination.Total) {
+			return vals, nil
+		}
+		pag.Offset += validatorsPerPage
+	}
+}
+
+// operatorSet maps consensus public keys of validators to their operators, as known from the last staking module fetch
+type operatorSet struct {
+	l         sync.Mutex
+	operators map[string]operator
+}
+
+type operator struct {
+	address string
+	moniker string
+}
+
+func pubKeyID(pk *codec_types.Any) string {
+	return pk.TypeUrl + "/" + string(pk.Value)
+}
+
+// resolveOperators fills operator address and moniker of validators from staking module.
+// Validators are fetched only when some of the set is not known yet.
+// State may be already pruned at height, in that case set is returned without them.
+func (c *Client) resolveOperators(ctx context.Context, vs *ValidatorSet) {
+	c.operators.l.Lock()
+	defer c.operators.l.Unlock()
+
+	if !c.operators.known(vs.Validators) {
+		operators, err := c.getStakingValidators(ctx, vs.Height)
+		if err != nil {
+			c.logger.Debug("[COSMOS-API] Cannot resolve validator operators", zap.Uint64("height", vs.Height), zap.Error(err))
+			return
+		}
+		if c.operators.operators == nil {
+			c.operators.operators = make(map[string]operator, len(operators))
+		}
+		for _, op := range operators {
+			if op.ConsensusPubkey != nil {
+				c.operators.operators[pubKeyID(op.ConsensusPubkey)] = operator{address: op.OperatorAddress, moniker: op.Description.Moniker}
+			}
+		}
+	}
+
+	for i, v := range vs.Validators {
+		if v.pubKey == nil {
+			continue
+		}
+		if op, ok := c.operators.operators[pubKeyID(v.pubKey)]; ok {
+			vs.Validators[i].OperatorAddress = op.address
+			vs.Validators[i].Moniker = op.moniker
+		}
+	}
+}
+
+// known checks if operators of all validators are known, has to be called with lock held
+func (ops *operatorSet) known(vals []Validator) bool {
+	for _, v := range vals {
+		if v.pubKey == nil {
+			continue
+		}
+		if _, ok := ops.operators[pubKeyID(v.pubKey)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// getStakingValidators fetches all validators registered in staking module at height
+func (c *Client) getStakingValidators(ctx context.Context, height uint64) (vals []stakingTypes.Validator, err error) {
+	pag := &query.PageRequest{Limit: validatorsPerPage}
+	for {
+		var res *stakingTypes.QueryValidatorsResponse
+		err = c.call(ctx, "Validators", height, func(n *node) (err error) {
+			res, err = n.stakingClient.Validators(metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatUint(height, 10)),
+				&stakingTypes.QueryValidatorsRequest{Pagination: pag})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		vals = append(vals, res.Validators...)
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return vals, nil
+		}
+		pag = &query.PageRequest{Key: res.Pagination.NextKey, Limit: validatorsPerPage}
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codec_types "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (fc *fakeChain) validatorAddress(i int) []byte {
+	addr := make([]byte, 20)
+	copy(addr, fmt.Sprintf("validator-%d", i))
+	return addr
+}
+
+func (fc *fakeChain) validatorConsAddress(i int) string {
+	return sdk.ConsAddress(fc.validatorAddress(i)).String()
+}
+
+func (fc *fakeChain) validatorPubKey(i int) *codec_types.Any {
+	key := make([]byte, ed25519.PubKeySize)
+	copy(key, fmt.Sprintf("validator-%d", i))
+	pk, err := codec_types.NewAnyWithValue(&ed25519.PubKey{Key: key})
+	if err != nil {
+		panic(err)
+	}
+	return pk
+}
+
+// GetValidatorSetByHeight pages like tendermint, failing for page out of range
+func (fn *fakeNode) GetValidatorSetByHeight(ctx context.Context, req *tmservice.GetValidatorSetByHeightRequest) (*tmservice.GetValidatorSetByHeightResponse, error) {
+	if err := fn.request(); err != nil {
+		return nil, err
+	}
+
+	page, limit, err := query.ParsePagination(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	pages := (fn.chain.validators + limit - 1) / limit
+	if page > pages {
+		return nil, status.Errorf(codes.Unknown, "page should be within [1, %d] range, given %d", pages, page)
+	}
+
+	res := &tmservice.GetValidatorSetByHeightResponse{BlockHeight: req.Height}
+	for i := (page - 1) * limit; i < page*limit && i < fn.chain.validators; i++ {
+		res.Validators = append(res.Validators, &tmservice.Validator{
+			Address:          fn.chain.validatorConsAddress(i),
+			PubKey:           fn.chain.validatorPubKey(i),
+			VotingPower:      int64(1000 - i),
+			ProposerPriority: int64(i),
+		})
+	}
+	return res, nil
+}
+
+type fakeStakingService struct {
+	stakingTypes.UnimplementedQueryServer
+	fn *fakeNode
+}
+
+// Validators returns validators in pages of two, in reverse order than in validator set
+func (fss *fakeStakingService) Validators(ctx context.Context, req *stakingTypes.QueryValidatorsRequest) (*stakingTypes.QueryValidatorsResponse, error) {
+	if err := fss.fn.request(); err != nil {
+		return nil, err
+	}
+
+	var height int64
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if h := md.Get(grpctypes.GRPCBlockHeightHeader); len(h) > 0 {
+			height, _ = strconv.ParseInt(h[0], 10, 64)
+		}
+	}
+	if fss.fn.statePruned(height) {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to load state at height %d; version does not exist (latest height: %d)", height, fss.fn.chain.height)
+	}
+
+	start := 0
+	if req.Pagination != nil && len(req.Pagination.Key) > 0 {
+		start, _ = strconv.Atoi(string(req.Pagination.Key))
+	}
+
+	res := &stakingTypes.QueryValidatorsResponse{Pagination: &query.PageResponse{}}
+	for i := start; i < start+2 && i < fss.fn.chain.validators; i++ {
+		idx := fss.fn.chain.validators - 1 - i
+		res.Validators = append(res.Validators, stakingTypes.Validator{
+			OperatorAddress: fmt.Sprintf("cosmosvaloper%d", idx),
+			ConsensusPubkey: fss.fn.chain.validatorPubKey(idx),
+			Description:     stakingTypes.Description{Moniker: fmt.Sprintf("validator %d", idx)},
+		})
+	}
+	if start+2 < fss.fn.chain.validators {
+		res.Pagination.NextKey = []byte(strconv.Itoa(start + 2))
+	}
+	return res, nil
+}
+
+func TestClientGetValidatorSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		validators int
+		pruned     bool
+	}{
+		{name: "single page", validators: 5},
+		{name: "multiple pages", validators: 250},
+		{name: "full last page", validators: 200},
+		{name: "staking state pruned", validators: 5, pruned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &fakeChain{chainID: "test-1", height: 10, validators: tt.validators}
+			fn := startFakeNode(t, chain)
+			if tt.pruned {
+				fn.setEarliest(8)
+			}
+			cli := newTestClient(t, &ClientConfig{}, fn)
+
+			vs, err := cli.GetValidatorSet(context.Background(), 5)
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), vs.Height)
+			require.Len(t, vs.Validators, tt.validators)
+
+			for i, v := range vs.Validators {
+				require.Equal(t, chain.validatorConsAddress(i), v.Address)
+				require.Equal(t, int64(1000-i), v.VotingPower)
+				require.Equal(t, int64(i), v.ProposerPriority)
+				if tt.pruned {
+					require.Empty(t, v.OperatorAddress)
+					continue
+				}
+				require.Equal(t, fmt.Sprintf("cosmosvaloper%d", i), v.OperatorAddress)
+				require.Equal(t, fmt.Sprintf("validator %d", i), v.Moniker)
+			}
+		})
+	}
+}
+
+func TestClientGetValidatorSetCachesOperators(t *testing.T) {
+	chain := &fakeChain{chainID: "test-1", height: 10, validators: 5}
+	fn := startFakeNode(t, chain)
+	cli := newTestClient(t, &ClientConfig{}, fn)
+	ctx := context.Background()
+
+	_, err := cli.GetValidatorSet(ctx, 5)
+	require.NoError(t, err)
+
+	// only the validator set is fetched, staking validators are known
+	served := fn.served()
+	vs, err := cli.GetValidatorSet(ctx, 6)
+	require.NoError(t, err)
+	require.Equal(t, served+1, fn.served())
+	for i, v := range vs.Validators {
+		require.Equal(t, fmt.Sprintf("cosmosvaloper%d", i), v.OperatorAddress)
+		require.Equal(t, fmt.Sprintf("validator %d", i), v.Moniker)
+	}
+
+	// new validator joins the set
+	chain.validators = 6
+	served = fn.served()
+	vs, err = cli.GetValidatorSet(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, served+1+3, fn.served())
+	require.Equal(t, "cosmosvaloper5", vs.Validators[5].OperatorAddress)
+}
@@ -0,0 +1,39 @@
+package client
+
+import "github.com/figment-networks/indexer-manager/structs"
+
+// Checkpoint is sent after every height of range task sent completely.
+// Task may be resumed with it, from the next height.
+type Checkpoint struct {
+	Height uint64 `json:"height"`
+}
+
+// RangeProgress is payload of final END of range task which failed, was cancelled or timed out,
+// heights from StartHeight to LastHeight were sent completely
+type RangeProgress struct {
+	StartHeight uint64 `json:"start_height"`
+	EndHeight   uint64 `json:"end_height"`
+	// LastHeight the last height sent completely (0 - none was), it's checkpoint to resume the task from
+	LastHeight   uint64 `json:"last_height"`
+	FailedHeight uint64 `json:"failed_height"`
+}
+
+func newRangeProgress(hr structs.HeightRange, last uint64) RangeProgress {
+	rp := RangeProgress{StartHeight: hr.StartHeight, EndHeight: hr.EndHeight, FailedHeight: hr.StartHeight}
+	if last >= hr.StartHeight && last > 0 {
+		rp.LastHeight = last
+		rp.FailedHeight = last + 1
+	}
+	return rp
+}
+
+// resumeRange returns the rest of the range after checkpoint, false when nothing is left
+func resumeRange(hr structs.HeightRange, checkpoint uint64) (structs.HeightRange, bool) {
+	if checkpoint >= hr.EndHeight {
+		return hr, false
+	}
+	if checkpoint >= hr.StartHeight {
+		hr.StartHeight = checkpoint + 1
+	}
+	return hr, true
+}
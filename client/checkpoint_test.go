@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func rangeProgressOf(t *testing.T, resp cStructs.TaskResponse) (rp RangeProgress) {
+	t.Helper()
+	require.Equal(t, "END", resp.Type)
+	require.True(t, resp.Final)
+	require.NoError(t, json.Unmarshal(resp.Payload, &rp))
+	return rp
+}
+
+func checkpointsOf(t *testing.T, resps []cStructs.TaskResponse) (heights []uint64) {
+	t.Helper()
+	for _, r := range resps {
+		if r.Type != "Checkpoint" {
+			continue
+		}
+		cp := Checkpoint{}
+		require.NoError(t, json.Unmarshal(r.Payload, &cp))
+		heights = append(heights, cp.Height)
+	}
+	return heights
+}
+
+func TestGetTransactionsCheckpoints(t *testing.T) {
+	fg := &fakeGRPC{height: 20, txsPerBlock: 2}
+	ic := newTestClient(t, fg, Config{RangeWorkers: 3})
+	sender := &fakeSender{}
+
+	tr := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}})
+	ic.GetTransactions(context.Background(), tr, sender, fg)
+	resps := sender.waitFinal(t, tr.Id)
+
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, heightsOf(t, resps))
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, checkpointsOf(t, resps))
+	end := resps[len(resps)-1]
+	require.Equal(t, "END", end.Type)
+	require.Empty(t, end.Error.Msg)
+
+	// resumed from checkpoint only the rest of the range is sent
+	tr = taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}, Checkpoint: 3})
+	ic.GetTransactions(context.Background(), tr, sender, fg)
+	require.Equal(t, []uint64{4, 5}, heightsOf(t, sender.waitFinal(t, tr.Id)))
+
+	// range already sent completely
+	tr = taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}, Checkpoint: 5})
+	ic.GetTransactions(context.Background(), tr, sender, fg)
+	resps = sender.waitFinal(t, tr.Id)
+	require.Len(t, resps, 1)
+	require.Equal(t, "END", resps[0].Type)
+}
+
+func TestGetTransactionsRangeProgress(t *testing.T) {
+	t.Run("failed height", func(t *testing.T) {
+		fg := &fakeGRPC{height: 20, onBlock: func(ctx context.Context, height uint64) error {
+			if height == 7 {
+				return errors.New("block is not available")
+			}
+			return nil
+		}}
+		ic := newTestClient(t, fg, Config{RangeWorkers: 1})
+		sender := &fakeSender{}
+
+		tr := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 3, EndHeight: 10}})
+		ic.GetTransactions(context.Background(), tr, sender, fg)
+		resps := sender.waitFinal(t, tr.Id)
+
+		require.Equal(t, []uint64{3, 4, 5, 6}, heightsOf(t, resps))
+		end := resps[len(resps)-1]
+		require.Contains(t, end.Error.Msg, "block is not available")
+		require.Equal(t, RangeProgress{StartHeight: 3, EndHeight: 10, LastHeight: 6, FailedHeight: 7}, rangeProgressOf(t, end))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		fg := &fakeGRPC{height: 20, onBlock: func(bctx context.Context, height uint64) error {
+			if height == 5 {
+				cancel()
+				<-bctx.Done()
+				return bctx.Err()
+			}
+			return nil
+		}}
+		ic := newTestClient(t, fg, Config{RangeWorkers: 1})
+		sender := &fakeSender{}
+
+		tr := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 10}})
+		ic.GetTransactions(ctx, tr, sender, fg)
+		resps := sender.waitFinal(t, tr.Id)
+
+		end := resps[len(resps)-1]
+		require.Equal(t, "task cancelled", end.Error.Msg)
+		// progress says exactly which heights were sent, even though the task couldn't send anything more
+		rp := rangeProgressOf(t, end)
+		checkpoints := checkpointsOf(t, resps)
+		if len(checkpoints) == 0 {
+			require.Equal(t, RangeProgress{StartHeight: 1, EndHeight: 10, FailedHeight: 1}, rp)
+		} else {
+			last := checkpoints[len(checkpoints)-1]
+			require.Equal(t, RangeProgress{StartHeight: 1, EndHeight: 10, LastHeight: last, FailedHeight: last + 1}, rp)
+		}
+		require.LessOrEqual(t, rp.LastHeight, uint64(4))
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/figment-networks/indexing-engine/metrics"
@@ -21,6 +23,15 @@ import (
 const page = 100
 const blockchainEndpointLimit = 20
 
+// Task types specific to cosmos worker
+const (
+	ReqIDGetValidatorSet      = "GetValidatorSet"
+	ReqIDGetTransactionByHash = "GetTransactionByHash"
+	ReqIDSearchTransactions   = "SearchTransactions"
+	ReqIDSubscribeNewBlocks   = "SubscribeNewBlocks"
+	ReqIDCancelTask           = "CancelTask"
+)
+
 var (
 	getTransactionDuration        *metrics.GroupObserver
 	getLatestDuration             *metrics.GroupObserver
@@ -28,6 +39,9 @@ var (
 	getRewardDuration             *metrics.GroupObserver
 	getAccountBalanceDuration     *metrics.GroupObserver
 	getAccountDelegationsDuration *metrics.GroupObserver
+	getValidatorSetDuration       *metrics.GroupObserver
+	getTransactionByHashDuration  *metrics.GroupObserver
+	searchTransactionsDuration    *metrics.GroupObserver
 )
 
 type GRPC interface {
@@ -36,6 +50,46 @@ type GRPC interface {
 	GetReward(ctx context.Context, params structs.HeightAccount) (resp structs.GetRewardResponse, err error)
 	GetAccountBalance(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountBalanceResponse, err error)
 	GetAccountDelegations(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountDelegationsResponse, err error)
+	GetBlockEvents(ctx context.Context, block structs.Block) (evs []api.BlockEvent, err error)
+	GetValidatorSet(ctx context.Context, height uint64) (vs api.ValidatorSet, err error)
+	GetTransaction(ctx context.Context, hash string) (tx structs.Transaction, err error)
+	SearchTxsByEvents(ctx context.Context, q api.TxQuery, page, perPage uint64) (txs []structs.Transaction, total uint64, err error)
+	GetBlockSignatures(ctx context.Context, height uint64) (sigs api.BlockSignatures, err error)
+	GetBlockHeader(ctx context.Context, height uint64) (header api.BlockHeader, err error)
+	SubscribeNewBlocks(ctx context.Context, from uint64, out chan<- uint64) error
+	VerifyBlockHash(ctx context.Context, height uint64, hash string) (*api.ForkNotice, error)
+	CheckContinuity(ctx context.Context, height uint64, hash, prevHash string) (*api.ForkNotice, error)
+}
+
+const (
+	defaultStreamWorkers  = 20
+	defaultTipWorkers     = 2
+	defaultAccountWorkers = 5
+	defaultRangeWorkers   = 5
+)
+
+// Config of the IndexerClient
+type Config struct {
+	// StreamWorkers number of backfill tasks (GetTransactions, SearchTransactions) processed concurrently on every stream
+	StreamWorkers int
+	// TipWorkers number of tasks syncing the tip of the chain (GetLatest, SubscribeNewBlocks) processed concurrently on every stream
+	TipWorkers int
+	// AccountWorkers number of other tasks (account and single object queries) processed concurrently on every stream
+	AccountWorkers int
+	// RangeWorkers number of heights fetched concurrently in range tasks, may be overridden in task payload
+	RangeWorkers int
+	// MaxRangeWorkers upper bound of number of workers requested in task payload
+	MaxRangeWorkers int
+
+	// ValidatorSets enables sending validator set along with every block of the range
+	ValidatorSets bool
+	// BlockEvents enables sending BeginBlock and EndBlock events (from tendermint rpc) along with every block of the range
+	BlockEvents bool
+	// ExtendedBlocks enables sending block with full header and totals of its transactions
+	// as ExtendedBlock response along with every block of the range
+	ExtendedBlocks bool
+	// LiveBlocks enables SubscribeNewBlocks tasks, new blocks are followed with tendermint rpc websocket (RunLive)
+	LiveBlocks bool
 }
 
 type OutputSender interface {
@@ -50,24 +104,55 @@ type IndexerClient struct {
 	streams map[uuid.UUID]*cStructs.StreamAccess
 	sLock   sync.Mutex
 
+	// subscribers of new blocks by task id
+	subscribers map[uuid.UUID]*liveSubscriber
+	lLock       sync.Mutex
+
+	// tasks being processed, by stream and task id
+	tasks map[uuid.UUID]map[uuid.UUID]context.CancelFunc
+	tLock sync.Mutex
+
 	maximumHeightsToGet uint64
+	cfg                 Config
 }
 
 // NewIndexerClient is IndexerClient constructor
-func NewIndexerClient(ctx context.Context, logger *zap.Logger, grpc GRPC, maximumHeightsToGet uint64) *IndexerClient {
+func NewIndexerClient(ctx context.Context, logger *zap.Logger, grpc GRPC, maximumHeightsToGet uint64, cfg Config) *IndexerClient {
 	getTransactionDuration = endpointDuration.WithLabels("getTransactions")
 	getLatestDuration = endpointDuration.WithLabels("getLatest")
 	getBlockDuration = endpointDuration.WithLabels("getBlock")
 	getRewardDuration = endpointDuration.WithLabels("getReward")
 	getAccountBalanceDuration = endpointDuration.WithLabels("getAccountBalance")
 	getAccountDelegationsDuration = endpointDuration.WithLabels("getAccountDelegations")
+	getValidatorSetDuration = endpointDuration.WithLabels("getValidatorSet")
+	getTransactionByHashDuration = endpointDuration.WithLabels("getTransactionByHash")
+	searchTransactionsDuration = endpointDuration.WithLabels("searchTransactions")
 	api.InitMetrics()
 
+	if cfg.StreamWorkers <= 0 {
+		cfg.StreamWorkers = defaultStreamWorkers
+	}
+	if cfg.TipWorkers <= 0 {
+		cfg.TipWorkers = defaultTipWorkers
+	}
+	if cfg.AccountWorkers <= 0 {
+		cfg.AccountWorkers = defaultAccountWorkers
+	}
+	if cfg.RangeWorkers <= 0 {
+		cfg.RangeWorkers = defaultRangeWorkers
+	}
+	if cfg.MaxRangeWorkers < cfg.RangeWorkers {
+		cfg.MaxRangeWorkers = cfg.RangeWorkers
+	}
+
 	return &IndexerClient{
 		logger:              logger,
 		grpc:                grpc,
 		maximumHeightsToGet: maximumHeightsToGet,
+		cfg:                 cfg,
 		streams:             make(map[uuid.UUID]*cStructs.StreamAccess),
+		subscribers:         make(map[uuid.UUID]*liveSubscriber),
+		tasks:               make(map[uuid.UUID]map[uuid.UUID]context.CancelFunc),
 	}
 }
 
@@ -78,11 +163,12 @@ func (ic *IndexerClient) CloseStream(ctx context.Context, streamID uuid.UUID) er
 
 	ic.logger.Debug("[COSMOS-CLIENT] Close Stream", zap.Stringer("streamID", streamID))
 	delete(ic.streams, streamID)
+	ic.cancelStreamTasks(streamID)
 
 	return nil
 }
 
-// RegisterStream adds new listeners to the streams - currently fixed number per stream
+// RegisterStream adds new listener to the streams, with workers of every priority class
 func (ic *IndexerClient) RegisterStream(ctx context.Context, stream *cStructs.StreamAccess) error {
 	ic.logger.Debug("[COSMOS-CLIENT] Register Stream", zap.Stringer("streamID", stream.StreamID))
 	newStreamsMetric.WithLabels().Inc()
@@ -91,28 +177,31 @@ func (ic *IndexerClient) RegisterStream(ctx context.Context, stream *cStructs.St
 	defer ic.sLock.Unlock()
 	ic.streams[stream.StreamID] = stream
 
-	// Limit workers not to create new goroutines over and over again
-	for i := 0; i < 20; i++ {
-		go ic.Run(ctx, stream)
-	}
+	go ic.Run(ctx, stream)
 
 	return nil
 }
 
-// Run listens on the stream events (new tasks)
-func (ic *IndexerClient) Run(ctx context.Context, stream *cStructs.StreamAccess) {
+// runWorker processes tasks of the priority class, until the stream is finished
+func (ic *IndexerClient) runWorker(ctx context.Context, stream *cStructs.StreamAccess, class string, tasks <-chan queuedTask) {
 	for {
 		select {
 		case <-ctx.Done():
-			ic.sLock.Lock()
-			delete(ic.streams, stream.StreamID)
-			ic.sLock.Unlock()
 			return
 		case <-stream.Finish:
 			return
-		case taskRequest := <-stream.RequestListener:
-			receivedRequestsMetric.WithLabels(taskRequest.Type).Inc()
-			tctx, cancel := context.WithTimeout(ctx, time.Minute*10)
+		case qt := <-tasks:
+			taskRequest := qt.tr
+			if err := qt.ctx.Err(); err != nil { // cancelled while waiting in the queue
+				stream.Send(cStructs.TaskResponse{Id: taskRequest.Id, Type: "END", Error: contextTaskError(err), Final: true})
+				qt.finish()
+				continue
+			}
+			busyWorkersMetric.WithLabels(class).Inc()
+			tctx, cancel := context.WithTimeout(qt.ctx, taskTimeout)
+			if class == classBackfill {
+				tctx = api.WithPriority(tctx, api.PriorityLow)
+			}
 			switch taskRequest.Type {
 			case structs.ReqIDGetTransactions:
 				ic.GetTransactions(tctx, taskRequest, stream, ic.grpc)
@@ -124,6 +213,15 @@ func (ic *IndexerClient) Run(ctx context.Context, stream *cStructs.StreamAccess)
 				ic.GetAccountBalance(tctx, taskRequest, stream, ic.grpc)
 			case structs.ReqIDAccountDelegations:
 				ic.GetAccountDelegations(tctx, taskRequest, stream, ic.grpc)
+			case ReqIDGetValidatorSet:
+				ic.GetValidatorSet(tctx, taskRequest, stream, ic.grpc)
+			case ReqIDGetTransactionByHash:
+				ic.GetTransactionByHash(tctx, taskRequest, stream, ic.grpc)
+			case ReqIDSearchTransactions:
+				ic.SearchTransactions(tctx, taskRequest, stream, ic.grpc)
+			case ReqIDSubscribeNewBlocks:
+				// subscription outlives the task timeout, it ends with the stream
+				ic.SubscribeNewBlocks(ctx, taskRequest, stream)
 			default:
 				stream.Send(cStructs.TaskResponse{
 					Id:    taskRequest.Id,
@@ -132,17 +230,46 @@ func (ic *IndexerClient) Run(ctx context.Context, stream *cStructs.StreamAccess)
 				})
 			}
 			cancel()
+			qt.finish()
+			busyWorkersMetric.WithLabels(class).Dec()
 		}
 	}
 }
 
+// rangeRequest is payload of GetTransactions with optional number of workers
+// and checkpoint to resume the task from (the last height sent completely before)
+type rangeRequest struct {
+	structs.HeightRange
+	Workers    int    `json:"workers"`
+	Checkpoint uint64 `json:"checkpoint"`
+}
+
+// latestRequest is payload of GetLatest with optional number of workers
+type latestRequest struct {
+	structs.LatestDataRequest
+	Workers int `json:"workers"`
+}
+
+// rangeConfig returns config of range task with number of workers requested in payload (0 - default),
+// bounded by MaxRangeWorkers
+func (ic *IndexerClient) rangeConfig(workers int) Config {
+	cfg := ic.cfg
+	if workers > 0 {
+		cfg.RangeWorkers = workers
+	}
+	if cfg.RangeWorkers > cfg.MaxRangeWorkers {
+		cfg.RangeWorkers = cfg.MaxRangeWorkers
+	}
+	return cfg
+}
+
 // GetTransactions gets new transactions and blocks from cosmos for given range
 func (ic *IndexerClient) GetTransactions(ctx context.Context, tr cStructs.TaskRequest, stream OutputSender, client GRPC) {
 	timer := metrics.NewTimer(getTransactionDuration)
 	defer timer.ObserveDuration()
 
-	hr := &structs.HeightRange{}
-	err := json.Unmarshal(tr.Payload, hr)
+	req := &rangeRequest{}
+	err := json.Unmarshal(tr.Payload, req)
 	if err != nil {
 		ic.logger.Debug("[COSMOS-CLIENT] Cannot unmarshal payload", zap.String("contents", string(tr.Payload)))
 		stream.Send(cStructs.TaskResponse{
@@ -153,6 +280,7 @@ func (ic *IndexerClient) GetTransactions(ctx context.Context, tr cStructs.TaskRe
 		return
 	}
 
+	hr := &req.HeightRange
 	if hr.EndHeight == 0 {
 		stream.Send(cStructs.TaskResponse{
 			Id:    tr.Id,
@@ -162,6 +290,16 @@ func (ic *IndexerClient) GetTransactions(ctx context.Context, tr cStructs.TaskRe
 		return
 	}
 
+	if req.Checkpoint > 0 {
+		rest, ok := resumeRange(*hr, req.Checkpoint)
+		if !ok {
+			stream.Send(cStructs.TaskResponse{Id: tr.Id, Type: "END", Final: true})
+			return
+		}
+		ic.logger.Debug("[COSMOS-CLIENT] Resuming range", zap.Stringer("taskID", tr.Id), zap.Uint64("checkpoint", req.Checkpoint), zap.Uint64("start", rest.StartHeight))
+		hr = &rest
+	}
+
 	sCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -169,17 +307,11 @@ func (ic *IndexerClient) GetTransactions(ctx context.Context, tr cStructs.TaskRe
 	fin := make(chan bool, 2)
 
 	// (lukanus): in separate goroutine take transaction format wrap it in transport message and send
-	go sendResp(sCtx, tr.Id, out, ic.logger, stream, fin)
+	go sendRangeResp(sCtx, tr.Id, *hr, out, ic.logger, stream, fin)
 
-	if err := getRange(sCtx, ic.logger, client, *hr, out); err != nil {
-		stream.Send(cStructs.TaskResponse{
-			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: err.Error()},
-			Final: true,
-		})
-		ic.logger.Error("[COSMOS-CLIENT] Error getting range (Get Transactions) ", zap.Error(err), zap.Stringer("taskID", tr.Id))
-		close(out)
-		return
+	if last, err := getRange(sCtx, ic.logger, client, ic.rangeConfig(req.Workers), *hr, nil, out); err != nil {
+		ic.logger.Error("[COSMOS-CLIENT] Error getting range (Get Transactions) ", zap.Error(err), zap.Stringer("taskID", tr.Id), zap.Uint64("last", last))
+		sendOut(sCtx, out, cStructs.OutResp{Type: "Error", Error: err})
 	}
 	close(out)
 
@@ -215,7 +347,18 @@ func (ic *IndexerClient) GetBlock(ctx context.Context, tr cStructs.TaskRequest,
 		ic.logger.Error("Error getting block", zap.Error(err))
 		stream.Send(cStructs.TaskResponse{
 			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: "Error getting block data " + err.Error()},
+			Error: taskError("Error getting block data ", err),
+			Final: true,
+		})
+		return
+	}
+
+	sigs, err := client.GetBlockSignatures(ctx, block.Height)
+	if err != nil {
+		ic.logger.Error("Error getting block signatures", zap.Error(err))
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: taskError("Error getting block signatures ", err),
 			Final: true,
 		})
 		return
@@ -225,13 +368,184 @@ func (ic *IndexerClient) GetBlock(ctx context.Context, tr cStructs.TaskRequest,
 	out <- cStructs.OutResp{
 		ID:      tr.Id,
 		Type:    "Block",
-		Payload: block,
+		Payload: api.SignedBlock{Block: block, BlockSignatures: sigs},
 	}
 	close(out)
 
 	sendResp(ctx, tr.Id, out, ic.logger, stream, nil)
 }
 
+// GetValidatorSet gets validator set active at height
+func (ic *IndexerClient) GetValidatorSet(ctx context.Context, tr cStructs.TaskRequest, stream *cStructs.StreamAccess, client GRPC) {
+	timer := metrics.NewTimer(getValidatorSetDuration)
+	defer timer.ObserveDuration()
+
+	hr := &structs.HeightHash{}
+	err := json.Unmarshal(tr.Payload, hr)
+	if err != nil {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "Cannot unmarshal payload"},
+			Final: true,
+		})
+		return
+	}
+
+	if hr.Height == 0 {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "height is zero"},
+			Final: true,
+		})
+		return
+	}
+
+	vs, err := client.GetValidatorSet(ctx, hr.Height)
+	if err != nil {
+		ic.logger.Error("Error getting validator set", zap.Error(err))
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: taskError("Error getting validator set data ", err),
+			Final: true,
+		})
+		return
+	}
+
+	out := make(chan cStructs.OutResp, 1)
+	out <- cStructs.OutResp{
+		ID:      tr.Id,
+		Type:    "ValidatorSet",
+		Payload: vs,
+	}
+	close(out)
+
+	sendResp(ctx, tr.Id, out, ic.logger, stream, nil)
+}
+
+// GetTransactionByHash gets single transaction of given hash
+func (ic *IndexerClient) GetTransactionByHash(ctx context.Context, tr cStructs.TaskRequest, stream *cStructs.StreamAccess, client GRPC) {
+	timer := metrics.NewTimer(getTransactionByHashDuration)
+	defer timer.ObserveDuration()
+
+	hh := &structs.HeightHash{}
+	err := json.Unmarshal(tr.Payload, hh)
+	if err != nil {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "Cannot unmarshal payload"},
+			Final: true,
+		})
+		return
+	}
+
+	if hh.Hash == "" {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "hash is empty"},
+			Final: true,
+		})
+		return
+	}
+
+	t, err := client.GetTransaction(ctx, hh.Hash)
+	if err != nil {
+		ic.logger.Error("Error getting transaction", zap.Error(err), zap.String("hash", hh.Hash))
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: taskError("Error getting transaction data ", err),
+			Final: true,
+		})
+		return
+	}
+
+	out := make(chan cStructs.OutResp, 1)
+	out <- cStructs.OutResp{
+		ID:      tr.Id,
+		Type:    "Transaction",
+		Payload: t,
+	}
+	close(out)
+
+	sendResp(ctx, tr.Id, out, ic.logger, stream, nil)
+}
+
+// searchRequest is payload of SearchTransactions
+type searchRequest struct {
+	api.TxQuery
+	// Limit maximum number of transactions returned (0 - all)
+	Limit uint64 `json:"limit"`
+}
+
+// SearchTransactions gets transactions matching event query, sending them page by page
+func (ic *IndexerClient) SearchTransactions(ctx context.Context, tr cStructs.TaskRequest, stream OutputSender, client GRPC) {
+	timer := metrics.NewTimer(searchTransactionsDuration)
+	defer timer.ObserveDuration()
+
+	sr := &searchRequest{}
+	err := json.Unmarshal(tr.Payload, sr)
+	if err != nil {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "Cannot unmarshal payload"},
+			Final: true,
+		})
+		return
+	}
+
+	if err := sr.Validate(); err != nil {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: taskError("", err),
+			Final: true,
+		})
+		return
+	}
+
+	sCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make(chan cStructs.OutResp, page*2+1)
+	fin := make(chan bool, 2)
+	go sendResp(sCtx, tr.Id, out, ic.logger, stream, fin)
+
+	var sent uint64
+SEARCH_LOOP:
+	for p := uint64(0); ; p++ {
+		txs, total, err := client.SearchTxsByEvents(sCtx, sr.TxQuery, p, page)
+		if err != nil {
+			ic.logger.Error("[COSMOS-CLIENT] Error searching transactions", zap.Error(err), zap.Stringer("taskID", tr.Id))
+			// error goes after transactions already queued, as the final response
+			sendOut(sCtx, out, cStructs.OutResp{Type: "Error", Error: fmt.Errorf("error searching transactions: %w", err)})
+			break
+		}
+
+		for _, t := range txs {
+			if sr.Limit > 0 && sent >= sr.Limit {
+				break
+			}
+			if !sendOut(sCtx, out, cStructs.OutResp{ID: t.ID, Type: "Transaction", Payload: t}) {
+				break SEARCH_LOOP
+			}
+			sent++
+		}
+
+		if len(txs) == 0 || (p+1)*page >= total || (sr.Limit > 0 && sent >= sr.Limit) {
+			break
+		}
+	}
+	close(out)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-fin:
+			ic.logger.Debug("[COSMOS-CLIENT] Finished sending all", zap.Stringer("taskID", tr.Id), zap.Uint64("transactions", sent))
+			return
+		}
+	}
+}
+
 // GetAccountBalance gets account balance
 func (ic *IndexerClient) GetAccountBalance(ctx context.Context, tr cStructs.TaskRequest, stream *cStructs.StreamAccess, client GRPC) {
 	timer := metrics.NewTimer(getAccountBalanceDuration)
@@ -256,7 +570,7 @@ func (ic *IndexerClient) GetAccountBalance(ctx context.Context, tr cStructs.Task
 		ic.logger.Error("Error getting account balance", zap.Error(err))
 		stream.Send(cStructs.TaskResponse{
 			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: "Error getting account balance data " + err.Error()},
+			Error: taskError("Error getting account balance data ", err),
 			Final: true,
 		})
 		return
@@ -297,7 +611,7 @@ func (ic *IndexerClient) GetAccountDelegations(ctx context.Context, tr cStructs.
 		ic.logger.Error("Error getting account balance", zap.Error(err))
 		stream.Send(cStructs.TaskResponse{
 			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: "Error getting account balance data " + err.Error()},
+			Error: taskError("Error getting account balance data ", err),
 			Final: true,
 		})
 		return
@@ -338,7 +652,7 @@ func (ic *IndexerClient) GetReward(ctx context.Context, tr cStructs.TaskRequest,
 		ic.logger.Error("Error getting reward", zap.Error(err))
 		stream.Send(cStructs.TaskResponse{
 			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: "Error getting reward data " + err.Error()},
+			Error: taskError("Error getting reward data ", err),
 			Final: true,
 		})
 		return
@@ -361,7 +675,7 @@ func (ic *IndexerClient) GetLatest(ctx context.Context, tr cStructs.TaskRequest,
 	timer := metrics.NewTimer(getLatestDuration)
 	defer timer.ObserveDuration()
 
-	ldr := &structs.LatestDataRequest{}
+	ldr := &latestRequest{}
 	err := json.Unmarshal(tr.Payload, ldr)
 	if err != nil {
 		stream.Send(cStructs.TaskResponse{Id: tr.Id, Error: cStructs.TaskError{Msg: "Cannot unmarshal payload"}, Final: true})
@@ -373,28 +687,44 @@ func (ic *IndexerClient) GetLatest(ctx context.Context, tr cStructs.TaskRequest,
 	// (lukanus): Get latest block (height = 0)
 	block, err := client.GetBlock(sCtx, structs.HeightHash{})
 	if err != nil {
-		stream.Send(cStructs.TaskResponse{Id: tr.Id, Error: cStructs.TaskError{Msg: "Error getting block data " + err.Error()}, Final: true})
+		stream.Send(cStructs.TaskResponse{Id: tr.Id, Error: taskError("Error getting block data ", err), Final: true})
 		return
 	}
 
+	// history known by indexer has to be continued, otherwise it's notified about the fork instead
+	var tip *chainTip
+	if ldr.LastHash != "" && ldr.LastHeight > 0 {
+		notice, err := client.VerifyBlockHash(sCtx, ldr.LastHeight, ldr.LastHash)
+		if err != nil {
+			stream.Send(cStructs.TaskResponse{Id: tr.Id, Error: taskError("Error verifying last block ", err), Final: true})
+			return
+		}
+		if notice != nil {
+			ic.logger.Warn("[COSMOS-CLIENT] Last known block is not on the chain", zap.Stringer("taskID", tr.Id), zap.Any("notice", notice))
+			out := make(chan cStructs.OutResp, 1)
+			out <- cStructs.OutResp{Type: "ForkNotice", Payload: notice}
+			close(out)
+			sendResp(sCtx, tr.Id, out, ic.logger, stream, nil)
+			return
+		}
+		tip = &chainTip{height: ldr.LastHeight, hash: ldr.LastHash}
+	}
+
 	hr := getLastHeightRange(ldr.LastHeight, ic.maximumHeightsToGet, block.Height)
 
 	out := make(chan cStructs.OutResp, page*2+1)
 	fin := make(chan bool, 2)
 
 	// (lukanus): in separate goroutine take transaction format wrap it in transport message and send
-	go sendResp(sCtx, tr.Id, out, ic.logger, stream, fin)
+	go sendRangeResp(sCtx, tr.Id, hr, out, ic.logger, stream, fin)
 
 	ic.logger.Debug("[COSMOS-CLIENT] Getting Range", zap.Stringer("taskID", tr.Id), zap.Uint64("start", hr.StartHeight), zap.Uint64("end", hr.EndHeight))
-	if err := getRange(sCtx, ic.logger, ic.grpc, hr, out); err != nil {
-		stream.Send(cStructs.TaskResponse{
-			Id:    tr.Id,
-			Error: cStructs.TaskError{Msg: err.Error()},
-			Final: true,
-		})
-		ic.logger.Error("[COSMOS-CLIENT] Error getting range (Get Transactions) ", zap.Error(err), zap.Stringer("taskID", tr.Id))
-		close(out)
-		return
+	last, err := getRange(sCtx, ic.logger, ic.grpc, ic.rangeConfig(ldr.Workers), hr, tip, out)
+	if errors.Is(err, errChainFork) {
+		ic.logger.Warn("[COSMOS-CLIENT] Chain history is not continuous", zap.Stringer("taskID", tr.Id), zap.Any("heights", hr))
+	} else if err != nil {
+		ic.logger.Error("[COSMOS-CLIENT] Error getting range (Get Transactions) ", zap.Error(err), zap.Stringer("taskID", tr.Id), zap.Uint64("last", last))
+		sendOut(sCtx, out, cStructs.OutResp{Type: "Error", Error: err})
 	}
 	close(out)
 
@@ -435,7 +765,30 @@ func getLastHeightRange(lastKnownHeight, maximumHeightsToGet, lastBlockFromNetwo
 	}
 }
 
-func blockAndTx(ctx context.Context, logger *zap.Logger, client GRPC, height uint64) (block structs.Block, txs []structs.Transaction, err error) {
+// blockAndTx gets block with its transactions, sharing the fetch with concurrent tasks asking for the same height
+func blockAndTx(ctx context.Context, logger *zap.Logger, client GRPC, cfg Config, height uint64) (block structs.Block, txs []structs.Transaction, err error) {
+	fetch := func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+		return fetchBlockAndTx(ctx, logger, client, height)
+	}
+
+	f, shared, err := blockAndTxFlights.do(ctx, newFlightKey(client, cfg, height), fetch)
+	if err != nil {
+		return block, nil, fmt.Errorf("error fetching block: %d %w ", height, err)
+	}
+	if !shared {
+		return f.block, f.txs, f.err
+	}
+
+	coalescedRequestsMetric.WithLabels().Inc()
+	if f.aborted && ctx.Err() == nil {
+		// task that fetched it was cancelled (or the fetch panicked), it's not the failure of this one
+		logger.Debug("[COSMOS-CLIENT] Shared fetch cancelled, fetching again", zap.Uint64("block", height))
+		return fetchBlockAndTx(ctx, logger, client, height)
+	}
+	return f.block, f.txs, f.err
+}
+
+func fetchBlockAndTx(ctx context.Context, logger *zap.Logger, client GRPC, height uint64) (block structs.Block, txs []structs.Transaction, err error) {
 	defer logger.Sync()
 	logger.Debug("[COSMOS-CLIENT] Getting block", zap.Uint64("block", height))
 	block, err = client.GetBlock(ctx, structs.HeightHash{Height: uint64(height)})
@@ -455,38 +808,116 @@ func blockAndTx(ctx context.Context, logger *zap.Logger, client GRPC, height uin
 	return block, txs, err
 }
 
-func asyncBlockAndTx(ctx context.Context, logger *zap.Logger, wg *sync.WaitGroup, client GRPC, cinn chan hBTx) {
+func asyncBlockAndTx(ctx context.Context, logger *zap.Logger, wg *sync.WaitGroup, client GRPC, cfg Config, queued *int64, cinn chan hBTx) {
 	defer wg.Done()
 	for in := range cinn {
-		b, txs, err := blockAndTx(ctx, logger, client, in.Height)
+		atomic.AddInt64(queued, -1)
+		rangeQueueDepthMetric.WithLabels().Dec()
+		if !sendBlockAndTx(ctx, logger, client, cfg, in) {
+			return
+		}
+	}
+}
+
+// sendBlockAndTx fetches block of given height with all its data and sends it to in.Ch,
+// returns false if it failed (error is sent instead)
+func sendBlockAndTx(ctx context.Context, logger *zap.Logger, client GRPC, cfg Config, in hBTx) bool {
+	heightsInFlightMetric.WithLabels().Inc()
+	defer heightsInFlightMetric.WithLabels().Dec()
+
+	b, txs, err := blockAndTx(ctx, logger, client, cfg, in.Height)
+	if err != nil {
+		in.Ch <- cStructs.OutResp{
+			ID:    b.ID,
+			Error: err,
+			Type:  "Error",
+		}
+		return false
+	}
+
+	sigs, err := client.GetBlockSignatures(ctx, in.Height)
+	if err != nil {
+		in.Ch <- cStructs.OutResp{
+			ID:    b.ID,
+			Error: fmt.Errorf("error fetching block signatures: %d %w ", in.Height, err),
+			Type:  "Error",
+		}
+		return false
+	}
+	in.Ch <- cStructs.OutResp{
+		ID:      b.ID,
+		Type:    "Block",
+		Payload: api.SignedBlock{Block: b, BlockSignatures: sigs},
+	}
+
+	if cfg.ExtendedBlocks {
+		header, err := client.GetBlockHeader(ctx, in.Height)
 		if err != nil {
 			in.Ch <- cStructs.OutResp{
 				ID:    b.ID,
-				Error: err,
+				Error: fmt.Errorf("error fetching block header: %d %w ", in.Height, err),
 				Type:  "Error",
 			}
-			return
+			return false
 		}
 		in.Ch <- cStructs.OutResp{
 			ID:      b.ID,
-			Type:    "Block",
-			Payload: b,
-		}
-		if txs != nil {
-			for _, t := range txs {
-				in.Ch <- cStructs.OutResp{
-					ID:      t.ID,
-					Type:    "Transaction",
-					Payload: t,
-				}
+			Type:    "ExtendedBlock",
+			Payload: api.NewExtendedBlock(b, header, txs),
+		}
+	}
+
+	if cfg.BlockEvents {
+		evs, err := client.GetBlockEvents(ctx, b)
+		if err != nil {
+			in.Ch <- cStructs.OutResp{
+				ID:    b.ID,
+				Error: fmt.Errorf("error fetching block events: %d %w ", in.Height, err),
+				Type:  "Error",
+			}
+			return false
+		}
+		for _, ev := range evs {
+			in.Ch <- cStructs.OutResp{
+				ID:      b.ID,
+				Type:    "BlockEvent",
+				Payload: ev,
 			}
 		}
+	}
 
+	if cfg.ValidatorSets {
+		vs, err := client.GetValidatorSet(ctx, in.Height)
+		if err != nil {
+			in.Ch <- cStructs.OutResp{
+				ID:    b.ID,
+				Error: fmt.Errorf("error fetching validator set: %d %w ", in.Height, err),
+				Type:  "Error",
+			}
+			return false
+		}
 		in.Ch <- cStructs.OutResp{
-			ID:   b.ID,
-			Type: "Partial",
+			ID:      b.ID,
+			Type:    "ValidatorSet",
+			Payload: vs,
 		}
 	}
+
+	if txs != nil {
+		for _, t := range txs {
+			in.Ch <- cStructs.OutResp{
+				ID:      t.ID,
+				Type:    "Transaction",
+				Payload: t,
+			}
+		}
+	}
+
+	in.Ch <- cStructs.OutResp{
+		ID:   b.ID,
+		Type: "Partial",
+	}
+	return true
 }
 
 type hBTx struct {
@@ -495,22 +926,35 @@ type hBTx struct {
 	Ch     chan cStructs.OutResp
 }
 
-// getRange gets given range of blocks and transactions
-func getRange(ctx context.Context, logger *zap.Logger, client GRPC, hr structs.HeightRange, out chan cStructs.OutResp) (err error) {
+// getRange gets given range of blocks and transactions, every fully sent height is followed by Checkpoint.
+// Returns the last fully sent height (0 - none), on error it's the height before the failed one.
+// When tip is given every block has to point to the previous one, otherwise ForkNotice is sent and errChainFork returned.
+func getRange(ctx context.Context, logger *zap.Logger, client GRPC, cfg Config, hr structs.HeightRange, tip *chainTip, out chan cStructs.OutResp) (last uint64, err error) {
 	defer logger.Sync()
 
 	chIn := oHBTxPool.Get()
 	chOut := oHBTxPool.Get()
 
 	errored := make(chan bool, 7)
-	defer close(errored)
+
+	// heights of the range not picked up by workers yet
+	queued := int64(1)
+	if hr.EndHeight > hr.StartHeight {
+		queued += int64(hr.EndHeight - hr.StartHeight)
+	}
+	rangeQueueDepthMetric.WithLabels().Add(float64(queued))
+	defer func() { rangeQueueDepthMetric.WithLabels().Sub(float64(atomic.LoadInt64(&queued))) }()
 
 	wg := &sync.WaitGroup{}
-	for i := 0; i < 5; i++ {
+	for i := 0; i < cfg.RangeWorkers; i++ {
 		wg.Add(1)
-		go asyncBlockAndTx(ctx, logger, wg, client, chIn)
+		go asyncBlockAndTx(ctx, logger, wg, client, cfg, &queued, chIn)
 	}
-	go populateRange(chIn, chOut, hr, errored)
+	populated := make(chan struct{})
+	go func() {
+		defer close(populated)
+		populateRange(chIn, chOut, hr, errored)
+	}()
 
 RANGE_LOOP:
 	for {
@@ -526,20 +970,48 @@ RANGE_LOOP:
 			for resp := range o.Ch {
 				switch resp.Type {
 				case "Partial":
+					if !sendOut(ctx, out, cStructs.OutResp{ID: resp.ID, Type: "Checkpoint", Payload: Checkpoint{Height: o.Height}}) {
+						errored <- true
+						err = ctx.Err()
+						break RANGE_LOOP
+					}
+					last = o.Height
 					break INNER_LOOP
 				case "Error":
 					errored <- true // (lukanus): to close publisher and asyncBlockAndTx
 					err = resp.Error
-					out <- resp
 					break RANGE_LOOP
-				default:
-					out <- resp
+				case "Block":
+					if tip != nil {
+						notice, tErr := tip.next(ctx, client, resp.Payload.(api.SignedBlock).Block)
+						if tErr != nil || notice != nil {
+							errored <- true
+							drainHeight(o.Ch) // rest of the height is not sent, its producer can't be blocked
+							err = tErr
+							if notice != nil {
+								err = errChainFork
+								sendOut(ctx, out, cStructs.OutResp{ID: resp.ID, Type: "ForkNotice", Payload: notice})
+							}
+							break RANGE_LOOP
+						}
+					}
+				}
+
+				if !sendOut(ctx, out, resp) {
+					errored <- true
+					drainHeight(o.Ch)
+					err = ctx.Err()
+					break RANGE_LOOP
 				}
 			}
 			oRespPool.Put(o.Ch)
 		}
 	}
 
+	// chOut goes back to the pool, nothing can be sent to it anymore
+	close(errored)
+	<-populated
+
 	if err != nil { // (lukanus): discard everything on error, after error
 		wg.Wait() // (lukanus): make sure there are no outstanding producers
 	PURIFY_CHANNELS:
@@ -555,18 +1027,19 @@ RANGE_LOOP:
 							break PURIFY_INNER_CHANNELS
 						}
 					}
+					oRespPool.Put(o.Ch)
 				}
-				oRespPool.Put(o.Ch)
 			default:
 				break PURIFY_CHANNELS
 			}
 		}
 	}
 	oHBTxPool.Put(chOut)
-	return err
+	return last, err
 }
 
 func populateRange(in, out chan hBTx, hr structs.HeightRange, er chan bool) {
+	defer close(in)
 	height := hr.StartHeight
 
 	for {
@@ -574,13 +1047,14 @@ func populateRange(in, out chan hBTx, hr structs.HeightRange, er chan bool) {
 		select {
 		case out <- hBTxO:
 		case <-er:
-			break
+			oRespPool.Put(hBTxO.Ch)
+			return
 		}
 
 		select {
 		case in <- hBTxO:
 		case <-er:
-			break
+			return
 		}
 
 		height++
@@ -589,20 +1063,50 @@ func populateRange(in, out chan hBTx, hr structs.HeightRange, er chan bool) {
 			case out <- hBTx{Last: true}:
 			case <-er:
 			}
-			break
+			return
 		}
+	}
+}
 
+// sendOut passes response to sendResp unless ctx is done (sendResp is gone then), returns if it was passed
+func sendOut(ctx context.Context, out chan cStructs.OutResp, resp cStructs.OutResp) bool {
+	select {
+	case out <- resp:
+		return true
+	case <-ctx.Done():
+		return false
 	}
-	close(in)
 }
 
 // sendResp constructs protocol response and send it out to transport
 func sendResp(ctx context.Context, id uuid.UUID, in <-chan cStructs.OutResp, logger *zap.Logger, sender OutputSender, fin chan bool) {
+	sendTaskResp(ctx, id, in, logger, sender, fin, nil)
+}
+
+// sendRangeResp sends responses of range task. When the task fails or its context is done,
+// it ends with final END carrying RangeProgress - heights up to the last Checkpoint sent were sent completely.
+func sendRangeResp(ctx context.Context, id uuid.UUID, hr structs.HeightRange, in <-chan cStructs.OutResp, logger *zap.Logger, sender OutputSender, fin chan bool) {
+	sendTaskResp(ctx, id, in, logger, sender, fin, &hr)
+}
+
+func sendTaskResp(ctx context.Context, id uuid.UUID, in <-chan cStructs.OutResp, logger *zap.Logger, sender OutputSender, fin chan bool, hr *structs.HeightRange) {
 	b := &bytes.Buffer{}
 	enc := json.NewEncoder(b)
 	order := uint64(0)
 
-	var contextDone bool
+	var contextDone, failed bool
+	var checkpoint uint64
+	// progress is payload of final END of range task which didn't finish
+	progress := func() []byte {
+		if hr == nil {
+			return nil
+		}
+		p, err := json.Marshal(newRangeProgress(*hr, checkpoint))
+		if err != nil {
+			logger.Error("[COSMOS-CLIENT] Error encoding range progress", zap.Error(err))
+		}
+		return p
+	}
 
 SendLoop:
 	for {
@@ -612,6 +1116,8 @@ SendLoop:
 			break SendLoop
 		case t, ok := <-in:
 			if !ok && t.Type == "" {
+				// producer may have given up because of the context, not finished
+				contextDone = ctx.Err() != nil
 				break SendLoop
 			}
 			b.Reset()
@@ -630,23 +1136,51 @@ SendLoop:
 
 			b.Read(tr.Payload)
 			order++
+			if t.Error != nil {
+				// error ends the task after everything sent before it
+				tr.Final = true
+				tr.Error = taskError("", t.Error)
+				if cErr := ctx.Err(); cErr != nil && errors.Is(t.Error, cErr) {
+					// failed because task was cancelled or timed out
+					tr.Error = contextTaskError(cErr)
+				}
+				if hr != nil {
+					tr.Type = "END"
+					tr.Payload = progress()
+				}
+			}
 			err = sender.Send(tr)
 			if err != nil {
 				logger.Error("[COSMOS-CLIENT] Error sending data", zap.Error(err))
 			}
 			sendResponseMetric.WithLabels(t.Type, "yes").Inc()
+			if cp, ok := t.Payload.(Checkpoint); ok {
+				checkpoint = cp.Height
+			}
+			if tr.Final {
+				failed = true
+				break SendLoop
+			}
 		}
 	}
 
-	err := sender.Send(cStructs.TaskResponse{
-		Id:    id,
-		Type:  "END",
-		Order: order,
-		Final: true,
-	})
+	if !failed {
+		end := cStructs.TaskResponse{
+			Id:    id,
+			Type:  "END",
+			Order: order,
+			Final: true,
+		}
+		if contextDone {
+			// task was cancelled or timed out, data sent so far is not complete
+			end.Error = contextTaskError(ctx.Err())
+			end.Payload = progress()
+		}
+		err := sender.Send(end)
 
-	if err != nil {
-		logger.Error("[COSMOS-CLIENT] Error sending end", zap.Error(err))
+		if err != nil {
+			logger.Error("[COSMOS-CLIENT] Error sending end", zap.Error(err))
+		}
 	}
 
 	if fin != nil {
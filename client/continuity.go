@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+
+	"github.com/figment-networks/cosmos-worker/api"
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+)
+
+// chainTip is the last block streamed in order, the next block has to point to it
+type chainTip struct {
+	height uint64
+	hash   string
+}
+
+// next checks if block continues the chain and moves the tip to it, returns notice when it doesn't
+func (ct *chainTip) next(ctx context.Context, client GRPC, block structs.Block) (*api.ForkNotice, error) {
+	if block.Height == ct.height+1 {
+		notice, err := client.CheckContinuity(ctx, block.Height, block.Hash, ct.hash)
+		if err != nil || notice != nil {
+			return notice, err
+		}
+	}
+	ct.height, ct.hash = block.Height, block.Hash
+	return nil, nil
+}
+
+// drainHeight discards the rest of responses of the height
+func drainHeight(ch chan cStructs.OutResp) {
+	for resp := range ch {
+		if resp.Type == "Partial" || resp.Type == "Error" {
+			return
+		}
+	}
+}
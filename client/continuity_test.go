@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+func TestGetRangeContinuity(t *testing.T) {
+	hr := structs.HeightRange{StartHeight: 3, EndHeight: 8}
+
+	run := func(t *testing.T, fg *fakeGRPC) (last uint64, resps []cStructs.OutResp, err error) {
+		out := make(chan cStructs.OutResp, 100)
+		tip := &chainTip{height: 2, hash: blockHash(2)}
+		last, err = getRange(context.Background(), zaptest.NewLogger(t), fg, Config{RangeWorkers: 3}, hr, tip, out)
+		close(out)
+		for r := range out {
+			resps = append(resps, r)
+		}
+		return last, resps, err
+	}
+	blockHeights := func(resps []cStructs.OutResp) (heights []uint64) {
+		for _, r := range resps {
+			if r.Type == "Block" {
+				heights = append(heights, r.Payload.(api.SignedBlock).Height)
+			}
+		}
+		return heights
+	}
+
+	t.Run("continuous", func(t *testing.T) {
+		fg := &fakeGRPC{height: 10, onContinuity: func(height uint64, hash, prevHash string) (*api.ForkNotice, error) {
+			if hash != blockHash(height) || prevHash != blockHash(height-1) {
+				return nil, errors.New("unexpected check")
+			}
+			return nil, nil
+		}}
+		last, resps, err := run(t, fg)
+		require.NoError(t, err)
+		require.Equal(t, uint64(8), last)
+		require.Equal(t, []uint64{3, 4, 5, 6, 7, 8}, blockHeights(resps))
+		require.Equal(t, 6, fg.called("CheckContinuity"))
+	})
+
+	t.Run("block changed on node", func(t *testing.T) {
+		fg := &fakeGRPC{height: 10, onContinuity: func(height uint64, hash, prevHash string) (*api.ForkNotice, error) {
+			if height == 6 {
+				return nil, api.ErrBlockChanged
+			}
+			return nil, nil
+		}}
+		last, resps, err := run(t, fg)
+		require.True(t, errors.Is(err, api.ErrBlockChanged))
+		require.Equal(t, uint64(5), last)
+		// stale block is never sent
+		require.Equal(t, []uint64{3, 4, 5}, blockHeights(resps))
+	})
+
+	t.Run("fork", func(t *testing.T) {
+		notice := &api.ForkNotice{Height: 5, KnownHash: blockHash(5), NodeHash: "FORK5", Reason: api.ForkReasonLastBlockIDMismatch}
+		fg := &fakeGRPC{height: 10, onContinuity: func(height uint64, hash, prevHash string) (*api.ForkNotice, error) {
+			if height == 6 {
+				return notice, nil
+			}
+			return nil, nil
+		}}
+		last, resps, err := run(t, fg)
+		require.True(t, errors.Is(err, errChainFork))
+		require.Equal(t, uint64(5), last)
+		require.Equal(t, []uint64{3, 4, 5}, blockHeights(resps))
+		require.Equal(t, "ForkNotice", resps[len(resps)-1].Type)
+		require.Equal(t, notice, resps[len(resps)-1].Payload)
+	})
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/figment-networks/cosmos-worker/api"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+)
+
+// taskError creates task error out of error returned by api.
+// When cosmos nodes are down (circuit breaker is open) the message is always the same
+// so the manager can tell it apart and reschedule the task to another worker.
+func taskError(msg string, err error) cStructs.TaskError {
+	if errors.Is(err, api.ErrCircuitOpen) {
+		return cStructs.TaskError{Msg: api.ErrCircuitOpen.Error()}
+	}
+	return cStructs.TaskError{Msg: msg + err.Error()}
+}
+
+// errSubscriberLagging ends subscription of new blocks which doesn't receive them as fast as they are produced
+var errSubscriberLagging = errors.New("subscriber fell behind new blocks")
+
+// errTaskQueueFull ends task which can't be queued, there are too many tasks of its class waiting for workers
+var errTaskQueueFull = errors.New("too many tasks waiting, task rejected")
+
+// errChainFork is returned when streamed blocks are not continuation of history known by indexer
+var errChainFork = errors.New("chain history is not continuous")
+
+// contextTaskError is error of task which context is done before it finished
+func contextTaskError(err error) cStructs.TaskError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return cStructs.TaskError{Msg: "task timed out"}
+	}
+	return cStructs.TaskError{Msg: "task cancelled"}
+}
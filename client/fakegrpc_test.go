@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+// fakeGRPC is a deterministic chain of given height, every block has txsPerBlock transactions
+type fakeGRPC struct {
+	height      uint64
+	txsPerBlock int
+	// onBlock is called on every GetBlock of the height, returned error fails the call
+	onBlock func(ctx context.Context, height uint64) error
+	// newBlocks are heights sent to SubscribeNewBlocks
+	newBlocks chan uint64
+	// onContinuity is result of CheckContinuity (default - chain is continuous)
+	onContinuity func(height uint64, hash, prevHash string) (*api.ForkNotice, error)
+
+	l     sync.Mutex
+	calls map[string]int
+}
+
+func (fg *fakeGRPC) call(name string) {
+	fg.l.Lock()
+	defer fg.l.Unlock()
+	if fg.calls == nil {
+		fg.calls = make(map[string]int)
+	}
+	fg.calls[name]++
+}
+
+func (fg *fakeGRPC) called(name string) int {
+	fg.l.Lock()
+	defer fg.l.Unlock()
+	return fg.calls[name]
+}
+
+func blockHash(height uint64) string {
+	return fmt.Sprintf("HASH%d", height)
+}
+
+func (fg *fakeGRPC) GetBlock(ctx context.Context, params structs.HeightHash) (block structs.Block, er error) {
+	fg.call("GetBlock")
+	height := params.Height
+	if height == 0 {
+		height = fg.height
+	}
+	if fg.onBlock != nil {
+		if err := fg.onBlock(ctx, height); err != nil {
+			return block, err
+		}
+	}
+	return structs.Block{Height: height, Hash: blockHash(height), ChainID: "test-1", NumberOfTransactions: uint64(fg.txsPerBlock)}, nil
+}
+
+func (fg *fakeGRPC) SearchTx(ctx context.Context, r structs.HeightHash, block structs.Block, perPage uint64) (txs []structs.Transaction, err error) {
+	fg.call("SearchTx")
+	for i := 0; i < fg.txsPerBlock; i++ {
+		txs = append(txs, structs.Transaction{Height: r.Height, BlockHash: block.Hash, Hash: fmt.Sprintf("TX%d-%d", r.Height, i)})
+	}
+	return txs, nil
+}
+
+func (fg *fakeGRPC) GetReward(ctx context.Context, params structs.HeightAccount) (resp structs.GetRewardResponse, err error) {
+	fg.call("GetReward")
+	return resp, nil
+}
+
+func (fg *fakeGRPC) GetAccountBalance(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountBalanceResponse, err error) {
+	fg.call("GetAccountBalance")
+	return resp, nil
+}
+
+func (fg *fakeGRPC) GetAccountDelegations(ctx context.Context, params structs.HeightAccount) (resp structs.GetAccountDelegationsResponse, err error) {
+	fg.call("GetAccountDelegations")
+	return resp, nil
+}
+
+func (fg *fakeGRPC) GetBlockEvents(ctx context.Context, block structs.Block) (evs []api.BlockEvent, err error) {
+	fg.call("GetBlockEvents")
+	return nil, nil
+}
+
+func (fg *fakeGRPC) GetValidatorSet(ctx context.Context, height uint64) (vs api.ValidatorSet, err error) {
+	fg.call("GetValidatorSet")
+	return api.ValidatorSet{Height: height}, nil
+}
+
+func (fg *fakeGRPC) GetTransaction(ctx context.Context, hash string) (tx structs.Transaction, err error) {
+	fg.call("GetTransaction")
+	return structs.Transaction{Hash: hash}, nil
+}
+
+func (fg *fakeGRPC) SearchTxsByEvents(ctx context.Context, q api.TxQuery, page, perPage uint64) (txs []structs.Transaction, total uint64, err error) {
+	fg.call("SearchTxsByEvents")
+	return nil, 0, nil
+}
+
+func (fg *fakeGRPC) GetBlockSignatures(ctx context.Context, height uint64) (sigs api.BlockSignatures, err error) {
+	fg.call("GetBlockSignatures")
+	return api.BlockSignatures{LastCommitHeight: height - 1}, nil
+}
+
+func (fg *fakeGRPC) GetBlockHeader(ctx context.Context, height uint64) (header api.BlockHeader, err error) {
+	fg.call("GetBlockHeader")
+	return api.BlockHeader{Height: height, LastBlockID: api.BlockID{Hash: blockHash(height - 1)}}, nil
+}
+
+func (fg *fakeGRPC) SubscribeNewBlocks(ctx context.Context, from uint64, out chan<- uint64) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case h := <-fg.newBlocks:
+			select {
+			case out <- h:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (fg *fakeGRPC) VerifyBlockHash(ctx context.Context, height uint64, hash string) (*api.ForkNotice, error) {
+	return nil, nil
+}
+
+func (fg *fakeGRPC) CheckContinuity(ctx context.Context, height uint64, hash, prevHash string) (*api.ForkNotice, error) {
+	fg.call("CheckContinuity")
+	if fg.onContinuity != nil {
+		return fg.onContinuity(height, hash, prevHash)
+	}
+	return nil, nil
+}
+
+// fakeSender collects responses sent to the stream
+type fakeSender struct {
+	l     sync.Mutex
+	resps []cStructs.TaskResponse
+}
+
+func (fs *fakeSender) Send(tr cStructs.TaskResponse) error {
+	fs.l.Lock()
+	defer fs.l.Unlock()
+	fs.resps = append(fs.resps, tr)
+	return nil
+}
+
+// task returns responses of the task sent so far
+func (fs *fakeSender) task(id uuid.UUID) (resps []cStructs.TaskResponse) {
+	fs.l.Lock()
+	defer fs.l.Unlock()
+	for _, r := range fs.resps {
+		if r.Id == id {
+			resps = append(resps, r)
+		}
+	}
+	return resps
+}
+
+// waitFinal waits for final response of the task, returning all its responses
+func (fs *fakeSender) waitFinal(t *testing.T, id uuid.UUID) []cStructs.TaskResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if resps := fs.task(id); len(resps) > 0 && resps[len(resps)-1].Final {
+			return resps
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no final response of task %s, got %v", id, fs.task(id))
+	return nil
+}
+
+// newTestClient creates client logging nowhere, its goroutines may outlive the test
+func newTestClient(t *testing.T, grpc GRPC, cfg Config) *IndexerClient {
+	t.Helper()
+	return NewIndexerClient(context.Background(), zap.NewNop(), grpc, 1000, cfg)
+}
+
+func taskRequest(t *testing.T, typ string, payload interface{}) cStructs.TaskRequest {
+	t.Helper()
+	p, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return cStructs.TaskRequest{Id: uuid.New(), Type: typ, Payload: p}
+}
+
+// heightsOf returns heights of blocks among responses
+func heightsOf(t *testing.T, resps []cStructs.TaskResponse) (heights []uint64) {
+	t.Helper()
+	for _, r := range resps {
+		if r.Type != "Block" {
+			continue
+		}
+		b := api.SignedBlock{}
+		require.NoError(t, json.Unmarshal(r.Payload, &b))
+		heights = append(heights, b.Height)
+	}
+	return heights
+}
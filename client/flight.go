@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/figment-networks/indexer-manager/structs"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+// blockAndTxFlights coalesces concurrent fetches of the same height, of all tasks
+var blockAndTxFlights = &blockAndTxGroup{flights: make(map[flightKey]*blockAndTxFlight)}
+
+// errFlightAborted is result of the fetch which never returned (panicked)
+var errFlightAborted = errors.New("shared fetch aborted")
+
+// flightKey identifies fetch that can be shared - the same height of the same client
+// for tasks configured the same way
+type flightKey struct {
+	client GRPC
+	height uint64
+	opts   flightOptions
+}
+
+// flightOptions are options of the task shaping data of the height
+type flightOptions struct {
+	validatorSets  bool
+	blockEvents    bool
+	extendedBlocks bool
+}
+
+func newFlightKey(client GRPC, cfg Config, height uint64) flightKey {
+	return flightKey{
+		client: client,
+		height: height,
+		opts: flightOptions{
+			validatorSets:  cfg.ValidatorSets,
+			blockEvents:    cfg.BlockEvents,
+			extendedBlocks: cfg.ExtendedBlocks,
+		},
+	}
+}
+
+// blockAndTxFlight is fetch of block and its transactions in progress
+type blockAndTxFlight struct {
+	done  chan struct{}
+	block structs.Block
+	txs   []structs.Transaction
+	err   error
+	// aborted the fetch failed because of the task that started it - its context was done or fetch panicked
+	aborted bool
+	// priority the highest priority of tasks waiting for the fetch (guarded by group lock)
+	priority api.Priority
+}
+
+// blockAndTxGroup is singleflight of block and transactions fetches
+type blockAndTxGroup struct {
+	lock    sync.Mutex
+	flights map[flightKey]*blockAndTxFlight
+}
+
+// do runs fetch unless there is one in flight already, then it waits for its result instead.
+// Fetch is made with the highest priority of tasks waiting for it.
+// Returned transactions may be shared with other tasks, they must not be modified.
+func (g *blockAndTxGroup) do(ctx context.Context, key flightKey, fetch func(ctx context.Context) (structs.Block, []structs.Transaction, error)) (f *blockAndTxFlight, shared bool, err error) {
+	g.lock.Lock()
+	if f, ok := g.flights[key]; ok {
+		if p := api.PriorityOf(ctx); p < f.priority {
+			f.priority = p
+		}
+		g.lock.Unlock()
+		select {
+		case <-f.done:
+			return f, true, nil
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+	f = &blockAndTxFlight{done: make(chan struct{}), priority: api.PriorityOf(ctx), err: errFlightAborted, aborted: true}
+	g.flights[key] = f
+	g.lock.Unlock()
+
+	defer func() {
+		g.lock.Lock()
+		delete(g.flights, key)
+		g.lock.Unlock()
+		close(f.done)
+	}()
+
+	fctx := api.WithPriorityFunc(ctx, func() api.Priority {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		return f.priority
+	})
+	f.block, f.txs, f.err = fetch(fctx)
+	f.aborted = f.err != nil && ctx.Err() != nil
+
+	return f, false, nil
+}
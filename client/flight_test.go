@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+// waitHighPriority waits until calls made with ctx are of high priority - task of high priority joined the fetch
+func waitHighPriority(ctx context.Context) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for api.PriorityOf(ctx) != api.PriorityHigh {
+		if time.Now().After(deadline) {
+			return errors.New("priority not raised")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+func TestBlockAndTxGroup(t *testing.T) {
+	fg := &fakeGRPC{height: 10}
+	low := api.WithPriority(context.Background(), api.PriorityLow)
+
+	t.Run("fetch shared with the highest priority", func(t *testing.T) {
+		g := &blockAndTxGroup{flights: make(map[flightKey]*blockAndTxFlight)}
+		key := newFlightKey(fg, Config{}, 5)
+
+		var fetches int64
+		fetch := func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+			atomic.AddInt64(&fetches, 1)
+			if err := waitHighPriority(ctx); err != nil {
+				return structs.Block{}, nil, err
+			}
+			return structs.Block{Height: 5, Hash: blockHash(5)}, nil, nil
+		}
+
+		leader := make(chan *blockAndTxFlight)
+		go func() {
+			f, shared, _ := g.do(low, key, fetch)
+			if shared {
+				f = nil
+			}
+			leader <- f
+		}()
+
+		// wait for the leader to start the fetch
+		for {
+			g.lock.Lock()
+			_, ok := g.flights[key]
+			g.lock.Unlock()
+			if ok {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		f, shared, err := g.do(context.Background(), key, fetch)
+		require.NoError(t, err)
+		require.True(t, shared)
+		require.NoError(t, f.err)
+		require.Equal(t, blockHash(5), f.block.Hash)
+		require.Equal(t, f, <-leader)
+		require.Equal(t, int64(1), atomic.LoadInt64(&fetches))
+		require.Empty(t, g.flights)
+	})
+
+	t.Run("tasks configured differently don't share", func(t *testing.T) {
+		g := &blockAndTxGroup{flights: make(map[flightKey]*blockAndTxFlight)}
+		release := make(chan struct{})
+		started := make(chan struct{})
+		go g.do(context.Background(), newFlightKey(fg, Config{}, 5), func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+			close(started)
+			<-release
+			return structs.Block{Height: 5}, nil, nil
+		})
+		<-started
+		defer close(release)
+
+		for _, cfg := range []Config{{ExtendedBlocks: true}, {ValidatorSets: true}, {BlockEvents: true}} {
+			f, shared, err := g.do(context.Background(), newFlightKey(fg, cfg, 5), func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+				return structs.Block{Height: 5, Hash: blockHash(5)}, nil, nil
+			})
+			require.NoError(t, err)
+			require.False(t, shared)
+			require.Equal(t, blockHash(5), f.block.Hash)
+		}
+
+		f, shared, err := g.do(context.Background(), newFlightKey(&fakeGRPC{}, Config{}, 5), func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+			return structs.Block{Height: 5, Hash: blockHash(5)}, nil, nil
+		})
+		require.NoError(t, err)
+		require.False(t, shared)
+		require.Equal(t, blockHash(5), f.block.Hash)
+	})
+
+	t.Run("panicked fetch doesn't block the height", func(t *testing.T) {
+		g := &blockAndTxGroup{flights: make(map[flightKey]*blockAndTxFlight)}
+		key := newFlightKey(fg, Config{}, 5)
+
+		recovered := make(chan interface{})
+		go func() {
+			defer func() { recovered <- recover() }()
+			g.do(low, key, func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+				if err := waitHighPriority(ctx); err != nil {
+					return structs.Block{}, nil, err
+				}
+				panic("fetch failed")
+			})
+		}()
+		for {
+			g.lock.Lock()
+			_, ok := g.flights[key]
+			g.lock.Unlock()
+			if ok {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		f, shared, err := g.do(context.Background(), key, nil)
+		require.NoError(t, err)
+		require.True(t, shared)
+		require.True(t, f.aborted)
+		require.True(t, errors.Is(f.err, errFlightAborted))
+		require.Equal(t, "fetch failed", <-recovered)
+
+		// the next fetch of the height is not affected
+		f, shared, err = g.do(context.Background(), key, func(ctx context.Context) (structs.Block, []structs.Transaction, error) {
+			return structs.Block{Height: 5, Hash: blockHash(5)}, nil, nil
+		})
+		require.NoError(t, err)
+		require.False(t, shared)
+		require.Equal(t, blockHash(5), f.block.Hash)
+	})
+}
+
+func TestBlockAndTxLeaderCancelled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	lctx, cancel := context.WithCancel(api.WithPriority(context.Background(), api.PriorityLow))
+	defer cancel()
+
+	var calls int64
+	fg := &fakeGRPC{height: 10, txsPerBlock: 2, onBlock: func(ctx context.Context, height uint64) error {
+		if atomic.AddInt64(&calls, 1) > 1 {
+			return nil
+		}
+		// the leader is cancelled after the follower joined
+		if err := waitHighPriority(ctx); err != nil {
+			return err
+		}
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	leaderErr := make(chan error)
+	go func() {
+		_, _, err := blockAndTx(lctx, logger, fg, Config{}, 5)
+		leaderErr <- err
+	}()
+	for fg.called("GetBlock") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// follower retries on its own
+	block, txs, err := blockAndTx(context.Background(), logger, fg, Config{}, 5)
+	require.NoError(t, err)
+	require.Equal(t, blockHash(5), block.Hash)
+	require.Len(t, txs, 2)
+	require.True(t, errors.Is(<-leaderErr, context.Canceled))
+	require.Equal(t, 2, fg.called("GetBlock"))
+}
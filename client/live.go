@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// delay before fetching again height which failed in live mode
+var liveRetryDelay = time.Second
+
+const (
+	// liveMaxRetries number of times failed height is fetched again before it's skipped
+	liveMaxRetries = 5
+	// liveSubscriberBuffer number of heights waiting to be sent to subscriber, the one falling further behind is disconnected
+	liveSubscriberBuffer = 10
+)
+
+// SkippedHeight is sent to subscribers of new blocks instead of the height that couldn't be fetched
+type SkippedHeight struct {
+	Height uint64 `json:"height"`
+	Error  string `json:"error"`
+}
+
+// liveSubscriber is SubscribeNewBlocks task, receiving new blocks as they are produced
+type liveSubscriber struct {
+	out chan cStructs.OutResp
+	// heights are responses of heights waiting to be sent
+	heights chan []cStructs.OutResp
+	// lagging is closed when subscriber fell behind and is disconnected
+	lagging chan struct{}
+	done    <-chan struct{}
+	cancel  context.CancelFunc
+	// streamID is id of the stream the task came from
+	streamID uuid.UUID
+}
+
+// run sends heights to the subscriber, until it's done or disconnected for falling behind
+func (sub *liveSubscriber) run() {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case <-sub.lagging:
+			select {
+			case sub.out <- cStructs.OutResp{Type: "Error", Error: errSubscriberLagging}:
+			case <-sub.done:
+			}
+			return
+		case resps := <-sub.heights:
+			for _, resp := range resps {
+				select {
+				case sub.out <- resp:
+				case <-sub.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// SubscribeNewBlocks subscribes task to new blocks. Every new block is sent with all its data the same way
+// as in range tasks, until the stream is closed. Task never finishes on its own.
+func (ic *IndexerClient) SubscribeNewBlocks(ctx context.Context, tr cStructs.TaskRequest, stream *cStructs.StreamAccess) {
+	if !ic.cfg.LiveBlocks {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "Live blocks are not enabled on this worker"},
+			Final: true,
+		})
+		return
+	}
+
+	sCtx, cancel := context.WithCancel(ctx)
+	sub := &liveSubscriber{
+		out:      make(chan cStructs.OutResp, page*2+1),
+		heights:  make(chan []cStructs.OutResp, liveSubscriberBuffer),
+		lagging:  make(chan struct{}),
+		done:     sCtx.Done(),
+		cancel:   cancel,
+		streamID: stream.StreamID,
+	}
+
+	ic.lLock.Lock()
+	ic.subscribers[tr.Id] = sub
+	liveSubscribersMetric.WithLabels().Set(float64(len(ic.subscribers)))
+	ic.lLock.Unlock()
+	ic.logger.Debug("[COSMOS-CLIENT] Subscribed to new blocks", zap.Stringer("taskID", tr.Id), zap.Stringer("streamID", stream.StreamID))
+
+	go sub.run()
+	go func() {
+		sendResp(sCtx, tr.Id, sub.out, ic.logger, stream, nil)
+		cancel() // disconnected subscriber ends with its error
+	}()
+	go func() {
+		select {
+		case <-sCtx.Done():
+		case <-stream.Finish:
+		}
+		ic.unsubscribe(stream.StreamID, tr.Id)
+		cancel() // subscriber may be disconnected already
+	}()
+}
+
+// unsubscribe removes task of the stream from subscribers of new blocks, returns false if it wasn't subscribed
+func (ic *IndexerClient) unsubscribe(streamID, taskID uuid.UUID) bool {
+	ic.lLock.Lock()
+	defer ic.lLock.Unlock()
+
+	sub, ok := ic.subscribers[taskID]
+	if !ok || sub.streamID != streamID {
+		return false
+	}
+	sub.cancel()
+	delete(ic.subscribers, taskID)
+	liveSubscribersMetric.WithLabels().Set(float64(len(ic.subscribers)))
+	ic.logger.Debug("[COSMOS-CLIENT] Unsubscribed from new blocks", zap.Stringer("taskID", taskID))
+	return true
+}
+
+// RunLive follows new blocks of the chain and sends each of them to SubscribeNewBlocks tasks, until ctx is done.
+// Heights are processed one by one, failed one is retried, so subscribers don't miss any height
+// (also when connection to the node is lost in the meantime). Height which still fails after liveMaxRetries
+// is skipped, subscribers get SkippedHeight instead.
+func (ic *IndexerClient) RunLive(ctx context.Context) error {
+	heights := make(chan uint64, page)
+	errs := make(chan error, 1)
+	go func() { errs <- ic.grpc.SubscribeNewBlocks(ctx, 0, heights) }()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case height := <-heights:
+			ic.sendLiveHeight(ctx, height)
+		}
+	}
+}
+
+// sendLiveHeight fetches block of given height with its data and sends it to all subscribers,
+// retrying on error up to liveMaxRetries times
+func (ic *IndexerClient) sendLiveHeight(ctx context.Context, height uint64) {
+	ic.lLock.Lock()
+	subscribed := len(ic.subscribers) > 0
+	ic.lLock.Unlock()
+	if !subscribed {
+		liveHeightMetric.WithLabels().Set(float64(height))
+		return
+	}
+
+	for retry := 0; ; retry++ {
+		resps, err := ic.liveHeight(ctx, height)
+		if err == nil {
+			ic.broadcast(resps)
+			liveHeightMetric.WithLabels().Set(float64(height))
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if retry >= liveMaxRetries {
+			ic.logger.Error("[COSMOS-CLIENT] Skipping new block", zap.Uint64("height", height), zap.Error(err))
+			ic.broadcast([]cStructs.OutResp{{Type: "SkippedHeight", Payload: SkippedHeight{Height: height, Error: err.Error()}}})
+			liveHeightMetric.WithLabels().Set(float64(height))
+			return
+		}
+
+		ic.logger.Error("[COSMOS-CLIENT] Error getting new block", zap.Uint64("height", height), zap.Int("retry", retry), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(liveRetryDelay):
+		}
+	}
+}
+
+// liveHeight gets all responses of the height, as they are sent in range tasks
+func (ic *IndexerClient) liveHeight(ctx context.Context, height uint64) (resps []cStructs.OutResp, err error) {
+	ch := make(chan cStructs.OutResp, page)
+	go sendBlockAndTx(ctx, ic.logger, ic.grpc, ic.cfg, hBTx{Height: height, Ch: ch})
+
+	for resp := range ch {
+		switch resp.Type {
+		case "Partial":
+			return resps, nil
+		case "Error":
+			return nil, resp.Error
+		default:
+			resps = append(resps, resp)
+		}
+	}
+	return resps, nil
+}
+
+// broadcast queues responses of the height for every subscriber,
+// the ones which can't keep up are disconnected, so they don't stall the others
+func (ic *IndexerClient) broadcast(resps []cStructs.OutResp) {
+	ic.lLock.Lock()
+	defer ic.lLock.Unlock()
+
+	for id, sub := range ic.subscribers {
+		select {
+		case sub.heights <- resps:
+		default:
+			ic.logger.Warn("[COSMOS-CLIENT] Disconnecting subscriber of new blocks falling behind", zap.Stringer("taskID", id))
+			close(sub.lagging)
+			delete(ic.subscribers, id)
+			liveSubscribersMetric.WithLabels().Set(float64(len(ic.subscribers)))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/stretchr/testify/require"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+// nextResponse waits for the next response sent to the stream
+func nextResponse(t *testing.T, stream *cStructs.StreamAccess) cStructs.TaskResponse {
+	t.Helper()
+	select {
+	case resp := <-stream.ResponseListener:
+		return resp
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response sent")
+	}
+	return cStructs.TaskResponse{}
+}
+
+// nextLiveHeight waits for the next height sent to subscriber, returns its block or the skipped height
+func nextLiveHeight(t *testing.T, stream *cStructs.StreamAccess) (block api.SignedBlock, skipped SkippedHeight) {
+	t.Helper()
+	for {
+		resp := nextResponse(t, stream)
+		require.False(t, resp.Final, "subscription ended: %v", resp.Error)
+		switch resp.Type {
+		case "Block":
+			require.NoError(t, json.Unmarshal(resp.Payload, &block))
+			return block, skipped
+		case "SkippedHeight":
+			require.NoError(t, json.Unmarshal(resp.Payload, &skipped))
+			return block, skipped
+		}
+	}
+}
+
+func TestLiveBlocks(t *testing.T) {
+	liveRetryDelay = time.Millisecond
+
+	t.Run("new blocks sent to subscribers", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fg := &fakeGRPC{height: 10, txsPerBlock: 1, newBlocks: make(chan uint64)}
+		ic := newTestClient(t, fg, Config{LiveBlocks: true})
+
+		streams := []*cStructs.StreamAccess{cStructs.NewStreamAccess(), cStructs.NewStreamAccess()}
+		for _, stream := range streams {
+			ic.SubscribeNewBlocks(ctx, taskRequest(t, ReqIDSubscribeNewBlocks, struct{}{}), stream)
+		}
+		go ic.RunLive(ctx)
+
+		for h := uint64(11); h <= 13; h++ {
+			fg.newBlocks <- h
+			for _, stream := range streams {
+				block, _ := nextLiveHeight(t, stream)
+				require.Equal(t, h, block.Height)
+				tx := nextResponse(t, stream)
+				require.Equal(t, "Transaction", tx.Type)
+			}
+		}
+	})
+
+	t.Run("failing height is skipped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fg := &fakeGRPC{height: 10, newBlocks: make(chan uint64), onBlock: func(ctx context.Context, height uint64) error {
+			if height == 12 {
+				return errors.New("bad block")
+			}
+			return nil
+		}}
+		ic := newTestClient(t, fg, Config{LiveBlocks: true})
+		stream := cStructs.NewStreamAccess()
+		ic.SubscribeNewBlocks(ctx, taskRequest(t, ReqIDSubscribeNewBlocks, struct{}{}), stream)
+		go ic.RunLive(ctx)
+
+		for h := uint64(11); h <= 13; h++ {
+			fg.newBlocks <- h
+		}
+		block, _ := nextLiveHeight(t, stream)
+		require.Equal(t, uint64(11), block.Height)
+		_, skipped := nextLiveHeight(t, stream)
+		require.Equal(t, uint64(12), skipped.Height)
+		require.Contains(t, skipped.Error, "bad block")
+		block, _ = nextLiveHeight(t, stream)
+		require.Equal(t, uint64(13), block.Height)
+		require.Equal(t, 1+(liveMaxRetries+1)+1, fg.called("GetBlock"))
+	})
+
+	t.Run("subscriber falling behind is disconnected", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fg := &fakeGRPC{height: 10, newBlocks: make(chan uint64)}
+		ic := newTestClient(t, fg, Config{LiveBlocks: true})
+
+		slow, fast := cStructs.NewStreamAccess(), cStructs.NewStreamAccess()
+		ic.SubscribeNewBlocks(ctx, taskRequest(t, ReqIDSubscribeNewBlocks, struct{}{}), slow)
+		ic.SubscribeNewBlocks(ctx, taskRequest(t, ReqIDSubscribeNewBlocks, struct{}{}), fast)
+		go ic.RunLive(ctx)
+
+		// slow subscriber doesn't read anything, it doesn't stop the fast one
+		const heights = 400
+		go func() {
+			for h := uint64(11); h < 11+heights; h++ {
+				select {
+				case fg.newBlocks <- h:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		for h := uint64(11); h < 11+heights; h++ {
+			block, _ := nextLiveHeight(t, fast)
+			require.Equal(t, h, block.Height)
+		}
+
+		ic.lLock.Lock()
+		require.Len(t, ic.subscribers, 1)
+		ic.lLock.Unlock()
+
+		// what was sent before is followed by the error
+		for {
+			resp := nextResponse(t, slow)
+			if resp.Final {
+				require.Equal(t, errSubscriberLagging.Error(), resp.Error.Msg)
+				break
+			}
+		}
+	})
+}
@@ -33,4 +33,55 @@ var (
 		Desc:      "Responses to be sent from client",
 		Tags:      []string{"type", "final"},
 	})
+
+	busyWorkersMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "busy_workers",
+		Desc:      "Number of stream workers processing a task",
+		Tags:      []string{"class"},
+	})
+
+	queuedTasksMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "queued_tasks",
+		Desc:      "Number of tasks waiting for a worker of their priority class",
+		Tags:      []string{"class"},
+	})
+
+	rangeQueueDepthMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "range_queue_depth",
+		Desc:      "Number of heights of range tasks waiting for a worker",
+	})
+
+	heightsInFlightMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "heights_in_flight",
+		Desc:      "Number of heights being fetched by range workers",
+	})
+
+	liveSubscribersMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "live_subscribers",
+		Desc:      "Number of tasks subscribed to new blocks",
+	})
+
+	liveHeightMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "live_height",
+		Desc:      "Last height of new blocks followed in live mode",
+	})
+
+	coalescedRequestsMetric = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "coalesced_requests",
+		Desc:      "Number of block and transactions fetches served by concurrent fetch of the same height",
+	})
 )
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+)
+
+// Priority classes of tasks, every class has its own workers on every stream,
+// so long backfills don't hold back syncing the tip of the chain
+const (
+	classTip      = "tip"
+	classAccount  = "account"
+	classBackfill = "backfill"
+)
+
+func taskClass(typ string) string {
+	switch typ {
+	case structs.ReqIDLatestData, ReqIDSubscribeNewBlocks:
+		return classTip
+	case structs.ReqIDGetTransactions, ReqIDSearchTransactions:
+		return classBackfill
+	}
+	return classAccount
+}
+
+// maxQueuedTasks is maximum number of tasks waiting for workers of the class.
+// Tasks over it are rejected right away, other classes (and CancelTask) are not held back.
+const maxQueuedTasks = 100
+
+// queuedTask is task waiting for a worker, it's registered (and can be cancelled) since it's queued
+type queuedTask struct {
+	tr     cStructs.TaskRequest
+	ctx    context.Context
+	finish func()
+}
+
+// taskQueue is queue of tasks of the class waiting for its workers
+type taskQueue struct {
+	class   string
+	tasks   chan queuedTask
+	pending []queuedTask
+}
+
+// startWorkers starts workers of the class processing tasks of its queue
+func (ic *IndexerClient) startWorkers(ctx context.Context, stream *cStructs.StreamAccess, class string, workers int) *taskQueue {
+	q := &taskQueue{class: class, tasks: make(chan queuedTask)}
+	for i := 0; i < workers; i++ {
+		go ic.runWorker(ctx, stream, class, q.tasks)
+	}
+	return q
+}
+
+func (q *taskQueue) push(qt queuedTask) {
+	q.pending = append(q.pending, qt)
+	queuedTasksMetric.WithLabels(q.class).Inc()
+}
+
+func (q *taskQueue) full() bool {
+	return len(q.pending) >= maxQueuedTasks
+}
+
+// next returns the first pending task with channel to pass it to workers (nil when there is no task)
+func (q *taskQueue) next() (chan<- queuedTask, queuedTask) {
+	if len(q.pending) == 0 {
+		return nil, queuedTask{}
+	}
+	return q.tasks, q.pending[0]
+}
+
+// pop removes the first pending task, after it was passed to a worker
+func (q *taskQueue) pop() {
+	q.pending[0] = queuedTask{}
+	q.pending = q.pending[1:]
+	queuedTasksMetric.WithLabels(q.class).Dec()
+}
+
+// dropCancelled removes cancelled tasks from the queue, ending them right away
+func (q *taskQueue) dropCancelled(stream OutputSender) {
+	pending := q.pending[:0]
+	for _, qt := range q.pending {
+		if err := qt.ctx.Err(); err != nil {
+			stream.Send(cStructs.TaskResponse{Id: qt.tr.Id, Type: "END", Error: contextTaskError(err), Final: true})
+			qt.finish()
+			queuedTasksMetric.WithLabels(q.class).Dec()
+			continue
+		}
+		pending = append(pending, qt)
+	}
+	for i := len(pending); i < len(q.pending); i++ {
+		q.pending[i] = queuedTask{}
+	}
+	q.pending = pending
+}
+
+// discard removes all pending tasks, ending them with given error when the stream is still open
+func (q *taskQueue) discard(stream *cStructs.StreamAccess, msg string) {
+	open := true
+	select {
+	case <-stream.Finish:
+		open = false
+	default:
+	}
+
+	for _, qt := range q.pending {
+		if open {
+			stream.Send(cStructs.TaskResponse{Id: qt.tr.Id, Type: "END", Error: cStructs.TaskError{Msg: msg}, Final: true})
+		}
+		qt.finish()
+	}
+	queuedTasksMetric.WithLabels(q.class).Sub(float64(len(q.pending)))
+	q.pending = nil
+}
+
+// Run listens on the stream events (new tasks) and queues them for workers of their priority class,
+// rejecting tasks of the class which queue is full. CancelTask is handled right away,
+// it can't wait behind the tasks it's supposed to stop.
+func (ic *IndexerClient) Run(ctx context.Context, stream *cStructs.StreamAccess) {
+	tip := ic.startWorkers(ctx, stream, classTip, ic.cfg.TipWorkers)
+	account := ic.startWorkers(ctx, stream, classAccount, ic.cfg.AccountWorkers)
+	backfill := ic.startWorkers(ctx, stream, classBackfill, ic.cfg.StreamWorkers)
+	defer func() {
+		for _, q := range []*taskQueue{tip, account, backfill} {
+			q.discard(stream, "worker stopped before the task started")
+		}
+	}()
+
+	for {
+		tipCh, tipTask := tip.next()
+		accountCh, accountTask := account.next()
+		backfillCh, backfillTask := backfill.next()
+
+		select {
+		case <-ctx.Done():
+			ic.sLock.Lock()
+			delete(ic.streams, stream.StreamID)
+			ic.sLock.Unlock()
+			return
+		case <-stream.Finish:
+			return
+		case taskRequest, ok := <-stream.RequestListener:
+			if !ok {
+				return
+			}
+			receivedRequestsMetric.WithLabels(taskRequest.Type).Inc()
+			if taskRequest.Type == ReqIDCancelTask {
+				ic.CancelTask(ctx, taskRequest, stream)
+				tip.dropCancelled(stream)
+				account.dropCancelled(stream)
+				backfill.dropCancelled(stream)
+				continue
+			}
+			q := account
+			switch taskClass(taskRequest.Type) {
+			case classTip:
+				q = tip
+			case classBackfill:
+				q = backfill
+			}
+			if q.full() {
+				stream.Send(cStructs.TaskResponse{Id: taskRequest.Id, Type: "END", Error: cStructs.TaskError{Msg: errTaskQueueFull.Error()}, Final: true})
+				continue
+			}
+			tctx, finish := ic.registerTask(ctx, stream.StreamID, taskRequest.Id)
+			q.push(queuedTask{tr: taskRequest, ctx: tctx, finish: finish})
+		case tipCh <- tipTask:
+			tip.pop()
+		case accountCh <- accountTask:
+			account.pop()
+		case backfillCh <- backfillTask:
+			backfill.pop()
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/stretchr/testify/require"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+func TestPriorityClasses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// backfill heights block until the task is done, priority of every fetch is recorded
+	var l sync.Mutex
+	priorities := map[uint64]api.Priority{}
+	fg := &fakeGRPC{height: 20, onBlock: func(ctx context.Context, height uint64) error {
+		l.Lock()
+		priorities[height] = api.PriorityOf(ctx)
+		l.Unlock()
+		if height <= 10 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}
+	ic := newTestClient(t, fg, Config{StreamWorkers: 1, TipWorkers: 1, AccountWorkers: 1})
+	stream := cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, stream))
+	sent := collect(ctx, stream)
+
+	running := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}})
+	queued := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 6, EndHeight: 10}})
+	require.NoError(t, stream.Req(running))
+	require.NoError(t, stream.Req(queued))
+	for fg.called("GetBlock") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// tip and account tasks don't wait for busy backfill workers
+	latest := taskRequest(t, structs.ReqIDLatestData, latestRequest{LatestDataRequest: structs.LatestDataRequest{LastHeight: 18}})
+	balance := taskRequest(t, structs.ReqIDAccountBalance, structs.HeightAccount{Height: 18, Account: "cosmos1"})
+	require.NoError(t, stream.Req(latest))
+	require.NoError(t, stream.Req(balance))
+
+	resps := sent.waitFinal(t, latest.Id)
+	require.Empty(t, resps[len(resps)-1].Error.Msg)
+	require.Equal(t, []uint64{18, 19, 20}, heightsOf(t, resps))
+	resps = sent.waitFinal(t, balance.Id)
+	require.Empty(t, resps[len(resps)-1].Error.Msg)
+	require.Empty(t, sent.task(queued.Id))
+
+	// backfill calls don't use the reserve of tip sync
+	l.Lock()
+	require.Equal(t, api.PriorityLow, priorities[1])
+	for h := uint64(18); h <= 20; h++ {
+		require.Equal(t, api.PriorityHigh, priorities[h], "height %d", h)
+	}
+	l.Unlock()
+
+	// queued task is cancelled right away
+	cancelQueued := taskRequest(t, ReqIDCancelTask, cancelRequest{TaskID: queued.Id})
+	require.NoError(t, stream.Req(cancelQueued))
+	resps = sent.waitFinal(t, cancelQueued.Id)
+	require.Empty(t, resps[0].Error.Msg)
+	resps = sent.waitFinal(t, queued.Id)
+	require.Len(t, resps, 1)
+	require.Equal(t, "task cancelled", resps[0].Error.Msg)
+	require.Empty(t, sent.task(running.Id))
+}
+
+func TestTaskQueueBound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fg := &fakeGRPC{height: 20, onBlock: func(ctx context.Context, height uint64) error {
+		if height <= 10 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}
+	ic := newTestClient(t, fg, Config{StreamWorkers: 1, TipWorkers: 1})
+	stream := cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, stream))
+	sent := collect(ctx, stream)
+
+	// one task is running and the backfill queue is full
+	var tasks []cStructs.TaskRequest
+	for i := 0; i < 1+maxQueuedTasks; i++ {
+		task := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}})
+		require.NoError(t, stream.Req(task))
+		tasks = append(tasks, task)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runningTasks(ic, stream.StreamID) != 1+maxQueuedTasks {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d registered tasks, got %d", 1+maxQueuedTasks, runningTasks(ic, stream.StreamID))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// backfill over the limit is rejected right away
+	rejected := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}})
+	require.NoError(t, stream.Req(rejected))
+	resps := sent.waitFinal(t, rejected.Id)
+	require.Len(t, resps, 1)
+	require.Equal(t, errTaskQueueFull.Error(), resps[0].Error.Msg)
+	require.Equal(t, 1+maxQueuedTasks, runningTasks(ic, stream.StreamID))
+
+	// tip task and cancel of a queued task still go through
+	latest := taskRequest(t, structs.ReqIDLatestData, latestRequest{LatestDataRequest: structs.LatestDataRequest{LastHeight: 18}})
+	require.NoError(t, stream.Req(latest))
+	resps = sent.waitFinal(t, latest.Id)
+	require.Empty(t, resps[len(resps)-1].Error.Msg)
+	require.Equal(t, []uint64{18, 19, 20}, heightsOf(t, resps))
+
+	queued := tasks[len(tasks)-1]
+	cancelQueued := taskRequest(t, ReqIDCancelTask, cancelRequest{TaskID: queued.Id})
+	require.NoError(t, stream.Req(cancelQueued))
+	resps = sent.waitFinal(t, cancelQueued.Id)
+	require.Empty(t, resps[0].Error.Msg)
+	resps = sent.waitFinal(t, queued.Id)
+	require.Len(t, resps, 1)
+	require.Equal(t, "task cancelled", resps[0].Error.Msg)
+
+	// queue has room again
+	for runningTasks(ic, stream.StreamID) != maxQueuedTasks {
+		time.Sleep(time.Millisecond)
+	}
+	accepted := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 5}})
+	require.NoError(t, stream.Req(accepted))
+	for runningTasks(ic, stream.StreamID) != 1+maxQueuedTasks {
+		time.Sleep(time.Millisecond)
+	}
+	require.Empty(t, sent.task(accepted.Id))
+}
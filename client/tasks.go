@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// taskTimeout is maximum time of processing a task
+var taskTimeout = time.Minute * 10
+
+// cancelRequest is payload of CancelTask
+type cancelRequest struct {
+	TaskID uuid.UUID `json:"task_id"`
+}
+
+// registerTask registers task of the stream when it's queued, it can be cancelled until returned finish is called.
+// Timeout of the task starts when worker picks it up.
+func (ic *IndexerClient) registerTask(ctx context.Context, streamID, taskID uuid.UUID) (tctx context.Context, finish func()) {
+	tctx, cancel := context.WithCancel(ctx)
+
+	ic.tLock.Lock()
+	tasks, ok := ic.tasks[streamID]
+	if !ok {
+		tasks = make(map[uuid.UUID]context.CancelFunc)
+		ic.tasks[streamID] = tasks
+	}
+	tasks[taskID] = cancel
+	ic.tLock.Unlock()
+
+	return tctx, func() {
+		cancel()
+
+		ic.tLock.Lock()
+		defer ic.tLock.Unlock()
+		if tasks, ok := ic.tasks[streamID]; ok {
+			delete(tasks, taskID)
+			if len(tasks) == 0 {
+				delete(ic.tasks, streamID)
+			}
+		}
+	}
+}
+
+// cancelTask cancels context of queued or running task of the stream, returns false if the stream has no such task
+func (ic *IndexerClient) cancelTask(streamID, taskID uuid.UUID) bool {
+	ic.tLock.Lock()
+	defer ic.tLock.Unlock()
+
+	cancel, ok := ic.tasks[streamID][taskID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// cancelStreamTasks cancels all tasks of the stream
+func (ic *IndexerClient) cancelStreamTasks(streamID uuid.UUID) {
+	ic.tLock.Lock()
+	defer ic.tLock.Unlock()
+
+	for taskID, cancel := range ic.tasks[streamID] {
+		ic.logger.Debug("[COSMOS-CLIENT] Cancelling task of closed stream", zap.Stringer("taskID", taskID), zap.Stringer("streamID", streamID))
+		cancel()
+	}
+}
+
+// CancelTask cancels queued or running task (or subscription to new blocks) of id given in payload,
+// only tasks of the same stream can be cancelled.
+// Cancelled task stops sending data and ends with final "task cancelled" error.
+func (ic *IndexerClient) CancelTask(ctx context.Context, tr cStructs.TaskRequest, stream *cStructs.StreamAccess) {
+	cr := &cancelRequest{}
+	err := json.Unmarshal(tr.Payload, cr)
+	if err != nil {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "Cannot unmarshal payload"},
+			Final: true,
+		})
+		return
+	}
+
+	if !ic.cancelTask(stream.StreamID, cr.TaskID) && !ic.unsubscribe(stream.StreamID, cr.TaskID) {
+		stream.Send(cStructs.TaskResponse{
+			Id:    tr.Id,
+			Error: cStructs.TaskError{Msg: "There is no running task " + cr.TaskID.String()},
+			Final: true,
+		})
+		return
+	}
+
+	ic.logger.Debug("[COSMOS-CLIENT] Task cancelled", zap.Stringer("taskID", cr.TaskID))
+	stream.Send(cStructs.TaskResponse{Id: tr.Id, Type: "END", Final: true})
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// collect collects responses sent to the stream until ctx is done
+func collect(ctx context.Context, stream *cStructs.StreamAccess) *fakeSender {
+	fs := &fakeSender{}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-stream.ResponseListener:
+				fs.Send(resp)
+			}
+		}
+	}()
+	return fs
+}
+
+// blockingGRPC is a chain on which fetching blocks from the height blocks until the task is done
+func blockingGRPC(from uint64) *fakeGRPC {
+	return &fakeGRPC{height: 20, onBlock: func(ctx context.Context, height uint64) error {
+		if height >= from {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}
+}
+
+func runningTasks(ic *IndexerClient, streamID uuid.UUID) int {
+	ic.tLock.Lock()
+	defer ic.tLock.Unlock()
+	return len(ic.tasks[streamID])
+}
+
+func TestCancelTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fg := blockingGRPC(3)
+	ic := newTestClient(t, fg, Config{})
+	streamA, streamB := cStructs.NewStreamAccess(), cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, streamA))
+	require.NoError(t, ic.RegisterStream(ctx, streamB))
+	sentA, sentB := collect(ctx, streamA), collect(ctx, streamB)
+
+	task := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 10}})
+	require.NoError(t, streamA.Req(task))
+	for fg.called("GetBlock") < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// other stream can't cancel the task
+	cancelB := taskRequest(t, ReqIDCancelTask, cancelRequest{TaskID: task.Id})
+	require.NoError(t, streamB.Req(cancelB))
+	resps := sentB.waitFinal(t, cancelB.Id)
+	require.Equal(t, "There is no running task "+task.Id.String(), resps[0].Error.Msg)
+	for _, resp := range sentA.task(task.Id) {
+		require.False(t, resp.Final)
+	}
+	require.Equal(t, 1, runningTasks(ic, streamA.StreamID))
+
+	cancelA := taskRequest(t, ReqIDCancelTask, cancelRequest{TaskID: task.Id})
+	require.NoError(t, streamA.Req(cancelA))
+	resps = sentA.waitFinal(t, cancelA.Id)
+	require.Equal(t, "END", resps[0].Type)
+	require.Empty(t, resps[0].Error.Msg)
+
+	resps = sentA.waitFinal(t, task.Id)
+	require.Equal(t, "task cancelled", resps[len(resps)-1].Error.Msg)
+	require.Equal(t, []uint64{1, 2}, heightsOf(t, resps))
+	for runningTasks(ic, streamA.StreamID) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCancelTasksOfClosedStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fg := blockingGRPC(1)
+	ic := newTestClient(t, fg, Config{})
+	stream := cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, stream))
+	sent := collect(ctx, stream)
+
+	var tasks []cStructs.TaskRequest
+	for i := 0; i < 2; i++ {
+		task := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 10}})
+		require.NoError(t, stream.Req(task))
+		tasks = append(tasks, task)
+	}
+	for runningTasks(ic, stream.StreamID) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, ic.CloseStream(ctx, stream.StreamID))
+	for _, task := range tasks {
+		resps := sent.waitFinal(t, task.Id)
+		require.Equal(t, "task cancelled", resps[len(resps)-1].Error.Msg)
+	}
+	for runningTasks(ic, stream.StreamID) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { taskTimeout = timeout }(taskTimeout)
+	taskTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ic := newTestClient(t, blockingGRPC(2), Config{})
+	stream := cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, stream))
+	sent := collect(ctx, stream)
+
+	task := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 10}})
+	require.NoError(t, stream.Req(task))
+
+	resps := sent.waitFinal(t, task.Id)
+	end := resps[len(resps)-1]
+	require.Equal(t, "task timed out", end.Error.Msg)
+	require.Equal(t, RangeProgress{StartHeight: 1, EndHeight: 10, LastHeight: 1, FailedHeight: 2}, rangeProgressOf(t, end))
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/indexer-manager/structs"
+	cStructs "github.com/figment-networks/indexer-manager/worker/connectivity/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrency tracks the highest number of blocks fetched at once
+type concurrency struct {
+	l        sync.Mutex
+	current  int
+	max      int
+	duration time.Duration
+}
+
+func (c *concurrency) onBlock(ctx context.Context, height uint64) error {
+	c.l.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.l.Unlock()
+
+	time.Sleep(c.duration)
+
+	c.l.Lock()
+	c.current--
+	c.l.Unlock()
+	return nil
+}
+
+func (c *concurrency) highest() int {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.max
+}
+
+func TestRangeWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		workers  int
+		expected int
+	}{
+		{name: "configured", cfg: Config{RangeWorkers: 3}, expected: 3},
+		{name: "requested in payload", cfg: Config{RangeWorkers: 3, MaxRangeWorkers: 8}, workers: 6, expected: 6},
+		{name: "requested over maximum", cfg: Config{RangeWorkers: 3, MaxRangeWorkers: 4}, workers: 50, expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &concurrency{duration: 20 * time.Millisecond}
+			fg := &fakeGRPC{height: 100, onBlock: c.onBlock}
+			ic := newTestClient(t, fg, tt.cfg)
+			sender := &fakeSender{}
+
+			tr := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 1, EndHeight: 30}, Workers: tt.workers})
+			ic.GetTransactions(context.Background(), tr, sender, fg)
+			resps := sender.waitFinal(t, tr.Id)
+			require.Empty(t, resps[len(resps)-1].Error.Msg)
+			require.Len(t, heightsOf(t, resps), 30)
+			require.Equal(t, tt.expected, c.highest())
+		})
+	}
+}
+
+func TestStreamWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &concurrency{duration: 20 * time.Millisecond}
+	fg := &fakeGRPC{height: 100, onBlock: c.onBlock}
+	ic := newTestClient(t, fg, Config{StreamWorkers: 2, RangeWorkers: 1})
+	stream := cStructs.NewStreamAccess()
+	require.NoError(t, ic.RegisterStream(ctx, stream))
+	sent := collect(ctx, stream)
+
+	// every task fetches one height at a time, so blocks fetched at once are tasks processed at once
+	var tasks []cStructs.TaskRequest
+	for i := uint64(0); i < 6; i++ {
+		task := taskRequest(t, structs.ReqIDGetTransactions, rangeRequest{HeightRange: structs.HeightRange{StartHeight: 10*i + 1, EndHeight: 10*i + 2}})
+		require.NoError(t, stream.Req(task))
+		tasks = append(tasks, task)
+	}
+	for _, task := range tasks {
+		resps := sent.waitFinal(t, task.Id)
+		require.Empty(t, resps[len(resps)-1].Error.Msg)
+	}
+	require.Equal(t, 2, c.highest())
+}
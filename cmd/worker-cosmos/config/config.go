@@ -30,21 +30,69 @@ type Config struct {
 	CosmosGRPCAddr string `json:"cosmos_grpc_addr" envconfig:"COSMOS_GRPC_ADDR"`
 	ChainID        string `json:"chain_id" envconfig:"CHAIN_ID"`
 
+	CosmosGRPCTLS           bool   `json:"cosmos_grpc_tls" envconfig:"COSMOS_GRPC_TLS"`
+	CosmosGRPCTLSCAFile     string `json:"cosmos_grpc_tls_ca_file" envconfig:"COSMOS_GRPC_TLS_CA_FILE"`
+	CosmosGRPCTLSCertFile   string `json:"cosmos_grpc_tls_cert_file" envconfig:"COSMOS_GRPC_TLS_CERT_FILE"`
+	CosmosGRPCTLSKeyFile    string `json:"cosmos_grpc_tls_key_file" envconfig:"COSMOS_GRPC_TLS_KEY_FILE"`
+	CosmosGRPCTLSServerName string `json:"cosmos_grpc_tls_server_name" envconfig:"COSMOS_GRPC_TLS_SERVER_NAME"`
+	CosmosGRPCAuthToken     string `json:"cosmos_grpc_auth_token" envconfig:"COSMOS_GRPC_AUTH_TOKEN"`
+	CosmosGRPCAPIKey        string `json:"cosmos_grpc_api_key" envconfig:"COSMOS_GRPC_API_KEY"`
+	CosmosGRPCAPIKeyHeader  string `json:"cosmos_grpc_api_key_header" envconfig:"COSMOS_GRPC_API_KEY_HEADER" default:"x-api-key"`
+
+	// TendermintRPCAddr address of tendermint rpc (comma separated list), used for block events, transactions from blocks and live blocks
+	TendermintRPCAddr string `json:"tendermint_rpc_addr" envconfig:"TENDERMINT_RPC_ADDR"`
+	// TxSource is source of transactions: txs_event (GetTxsEvent) or block (block data and block_results, requires TendermintRPCAddr)
+	TxSource string `json:"tx_source" envconfig:"TX_SOURCE" default:"txs_event"`
+
 	Managers        string        `json:"managers" envconfig:"MANAGERS" default:"127.0.0.1:8085"`
 	ManagerInterval time.Duration `json:"manager_interval" envconfig:"MANAGER_INTERVAL" default:"10s"`
 	Hostname        string        `json:"hostname" envconfig:"HOSTNAME"`
 
 	MaximumHeightsToGet float64 `json:"maximum_heights_to_get" envconfig:"MAXIMUM_HEIGHTS_TO_GET" default:"10000"`
 	RequestsPerSecond   int64   `json:"requests_per_second" envconfig:"REQUESTS_PER_SECOND" default:"33"`
+	StreamWorkers       int     `json:"stream_workers" envconfig:"STREAM_WORKERS" default:"20"`
+	TipWorkers          int     `json:"tip_workers" envconfig:"TIP_WORKERS" default:"2"`
+	AccountWorkers      int     `json:"account_workers" envconfig:"ACCOUNT_WORKERS" default:"5"`
+	PriorityReserve     float64 `json:"priority_reserve" envconfig:"PRIORITY_RESERVE" default:"0.2"`
+	RangeWorkers        int     `json:"range_workers" envconfig:"RANGE_WORKERS" default:"5"`
+	MaxRangeWorkers     int     `json:"max_range_workers" envconfig:"MAX_RANGE_WORKERS" default:"20"`
+	TxPageConcurrency   int     `json:"tx_page_concurrency" envconfig:"TX_PAGE_CONCURRENCY" default:"4"`
+	IndexValidatorSets  bool    `json:"index_validator_sets" envconfig:"INDEX_VALIDATOR_SETS"`
+	IndexBlockEvents    bool    `json:"index_block_events" envconfig:"INDEX_BLOCK_EVENTS"`
+	ExtendedBlocks      bool    `json:"extended_blocks" envconfig:"EXTENDED_BLOCKS"`
+	LiveBlocks          bool    `json:"live_blocks" envconfig:"LIVE_BLOCKS"`
+	IndexMissedBlocks   bool    `json:"index_missed_blocks" envconfig:"INDEX_MISSED_BLOCKS"`
 
 	// Rollbar
 	RollbarAccessToken string `json:"rollbar_access_token" envconfig:"ROLLBAR_ACCESS_TOKEN"`
 	RollbarServerRoot  string `json:"rollbar_server_root" envconfig:"ROLLBAR_SERVER_ROOT" default:"github.com/figment-networks/cosmos-worker"`
 
 	HealthCheckInterval time.Duration `json:"health_check_interval" envconfig:"HEALTH_CHECK_INTERVAL" default:"10s"`
+	NodeMaxBlockLag     time.Duration `json:"node_max_block_lag" envconfig:"NODE_MAX_BLOCK_LAG" default:"2m"`
 
 	TimeoutBlockCall       time.Duration `json:"timeout_block_call" envconfig:"TIMEOUT_BLOCK_CALL" default:"30s"`
 	TimeoutTransactionCall time.Duration `json:"timeout_transaction_call" envconfig:"TIMEOUT_TRANSACTION_CALL" default:"30s"`
+
+	EndpointMaxFailures   int           `json:"endpoint_max_failures" envconfig:"ENDPOINT_MAX_FAILURES" default:"3"`
+	EndpointCooldown      time.Duration `json:"endpoint_cooldown" envconfig:"ENDPOINT_COOLDOWN" default:"10s"`
+	EndpointCheckInterval time.Duration `json:"endpoint_check_interval" envconfig:"ENDPOINT_CHECK_INTERVAL" default:"10s"`
+
+	MaxRetries     int           `json:"max_retries" envconfig:"MAX_RETRIES" default:"3"`
+	RetryBaseDelay time.Duration `json:"retry_base_delay" envconfig:"RETRY_BASE_DELAY" default:"200ms"`
+	RetryMaxDelay  time.Duration `json:"retry_max_delay" envconfig:"RETRY_MAX_DELAY" default:"5s"`
+
+	BreakerThreshold   int           `json:"breaker_threshold" envconfig:"BREAKER_THRESHOLD" default:"5"`
+	BreakerOpenTimeout time.Duration `json:"breaker_open_timeout" envconfig:"BREAKER_OPEN_TIMEOUT" default:"30s"`
+
+	BlockCacheSize   int           `json:"block_cache_size" envconfig:"BLOCK_CACHE_SIZE" default:"400"`
+	BlockCacheTipTTL time.Duration `json:"block_cache_tip_ttl" envconfig:"BLOCK_CACHE_TIP_TTL" default:"1s"`
+	AccountCacheSize int           `json:"account_cache_size" envconfig:"ACCOUNT_CACHE_SIZE" default:"10000"`
+
+	// DiskCacheDir enables persistent cache of blocks and transactions in given directory
+	DiskCacheDir     string `json:"disk_cache_dir" envconfig:"DISK_CACHE_DIR"`
+	DiskCacheMaxSize int64  `json:"disk_cache_max_size" envconfig:"DISK_CACHE_MAX_SIZE" default:"1073741824"`
+	// CacheAdminToken enables POST /cache/invalidate, requests have to carry it as bearer token
+	CacheAdminToken string `json:"cache_admin_token" envconfig:"CACHE_ADMIN_TOKEN"`
 }
 
 // FromFile reads the config from a file
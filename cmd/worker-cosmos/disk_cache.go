@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+// attachDiskCache attaches handler invalidating disk cache for range of heights
+// POST /cache/invalidate?start_height=X&end_height=Y with "Authorization: Bearer <token>" header.
+// It's not attached without token, as the mux is served publicly.
+func attachDiskCache(mux *http.ServeMux, dc *api.DiskCache, token string) {
+	if token == "" {
+		return
+	}
+
+	mux.HandleFunc("/cache/invalidate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if dc == nil {
+			http.Error(w, "disk cache is not enabled", http.StatusNotFound)
+			return
+		}
+
+		start, err := strconv.ParseUint(r.URL.Query().Get("start_height"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid start_height", http.StatusBadRequest)
+			return
+		}
+		end, err := strconv.ParseUint(r.URL.Query().Get("end_height"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid end_height", http.StatusBadRequest)
+			return
+		}
+
+		if err := dc.Invalidate(start, end); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
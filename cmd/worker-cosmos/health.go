@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/figment-networks/cosmos-worker/api"
+)
+
+type cosmosHealth struct {
+	Breaker   string               `json:"breaker"`
+	Endpoints []api.EndpointStatus `json:"endpoints"`
+}
+
+// attachCosmosHealth attaches handler reporting state of the connection to cosmos nodes
+func attachCosmosHealth(mux *http.ServeMux, cli *api.Client) {
+	mux.HandleFunc("/health/cosmos", func(w http.ResponseWriter, r *http.Request) {
+		st := cli.BreakerState()
+		if st == api.BreakerOpen {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(cosmosHealth{
+			Breaker:   st.String(),
+			Endpoints: cli.Endpoints(),
+		})
+	})
+}
@@ -109,21 +109,89 @@ func main() {
 		logger.Error(fmt.Errorf("cosmos grpc address is not set"))
 		return
 	}
-	grpcConn, dialErr := grpc.DialContext(ctx, cfg.CosmosGRPCAddr, grpc.WithInsecure())
-	if dialErr != nil {
-		logger.Error(fmt.Errorf("error dialing grpc: %w", dialErr))
+	if cfg.IndexBlockEvents && cfg.TendermintRPCAddr == "" {
+		logger.Error(fmt.Errorf("tendermint rpc address is required to index block events"))
 		return
 	}
-	defer grpcConn.Close()
+	if (cfg.CosmosGRPCAuthToken != "" || cfg.CosmosGRPCAPIKey != "") && !cfg.CosmosGRPCTLS {
+		logger.Error(fmt.Errorf("cosmos grpc auth token and api key require tls (COSMOS_GRPC_TLS)"))
+		return
+	}
+	tlsConfig := api.TLSConfig{
+		Enabled:    cfg.CosmosGRPCTLS,
+		CAFile:     cfg.CosmosGRPCTLSCAFile,
+		CertFile:   cfg.CosmosGRPCTLSCertFile,
+		KeyFile:    cfg.CosmosGRPCTLSKeyFile,
+		ServerName: cfg.CosmosGRPCTLSServerName,
+	}
 
-	apiClient := api.NewClient(logger.GetLogger(), grpcConn, &api.ClientConfig{
+	var grpcConns []*grpc.ClientConn
+	for _, addr := range strings.Split(cfg.CosmosGRPCAddr, ",") {
+		grpcConn, dialErr := api.Dial(ctx, strings.TrimSpace(addr), tlsConfig)
+		if dialErr != nil {
+			logger.Error(fmt.Errorf("error dialing grpc %s: %w", addr, dialErr))
+			return
+		}
+		defer grpcConn.Close()
+		grpcConns = append(grpcConns, grpcConn)
+	}
+
+	apiClient := api.NewClient(logger.GetLogger(), grpcConns, &api.ClientConfig{
 		ReqPerSecond:        int(cfg.RequestsPerSecond),
 		TimeoutBlockCall:    cfg.TimeoutBlockCall,
 		TimeoutSearchTxCall: cfg.TimeoutTransactionCall,
+		EndpointMaxFailures: cfg.EndpointMaxFailures,
+		EndpointCooldown:    cfg.EndpointCooldown,
+		MaxRetries:          cfg.MaxRetries,
+		RetryBaseDelay:      cfg.RetryBaseDelay,
+		RetryMaxDelay:       cfg.RetryMaxDelay,
+		PriorityReserve:     cfg.PriorityReserve,
+		BreakerThreshold:    cfg.BreakerThreshold,
+		BreakerOpenTimeout:  cfg.BreakerOpenTimeout,
+		AuthToken:           cfg.CosmosGRPCAuthToken,
+		APIKey:              cfg.CosmosGRPCAPIKey,
+		APIKeyHeader:        cfg.CosmosGRPCAPIKeyHeader,
+		BlockCacheSize:      cfg.BlockCacheSize,
+		BlockCacheTipTTL:    cfg.BlockCacheTipTTL,
+		AccountCacheSize:    cfg.AccountCacheSize,
+		MissedBlocks:        cfg.IndexMissedBlocks,
+		TendermintRPCAddr:   cfg.TendermintRPCAddr,
+		TxSource:            cfg.TxSource,
+		TxPageConcurrency:   cfg.TxPageConcurrency,
 	})
 
+	if cfg.DiskCacheDir != "" {
+		diskCache, err := api.OpenDiskCache(cfg.DiskCacheDir, cfg.DiskCacheMaxSize)
+		if err != nil {
+			logger.Error(err)
+			return
+		}
+		defer diskCache.Close()
+		apiClient.DiskCache = diskCache
+	}
+
+	go apiClient.MonitorEndpoints(ctx, cfg.EndpointCheckInterval)
+
 	grpcServer := grpc.NewServer()
-	workerClient := client.NewIndexerClient(ctx, logger.GetLogger(), apiClient, uint64(cfg.MaximumHeightsToGet))
+	workerClient := client.NewIndexerClient(ctx, logger.GetLogger(), apiClient, uint64(cfg.MaximumHeightsToGet), client.Config{
+		StreamWorkers:   cfg.StreamWorkers,
+		TipWorkers:      cfg.TipWorkers,
+		AccountWorkers:  cfg.AccountWorkers,
+		RangeWorkers:    cfg.RangeWorkers,
+		MaxRangeWorkers: cfg.MaxRangeWorkers,
+		ValidatorSets:   cfg.IndexValidatorSets,
+		BlockEvents:     cfg.IndexBlockEvents,
+		ExtendedBlocks:  cfg.ExtendedBlocks,
+		LiveBlocks:      cfg.LiveBlocks,
+	})
+
+	if cfg.LiveBlocks {
+		go func() {
+			if err := workerClient.RunLive(ctx); err != nil && ctx.Err() == nil {
+				logger.Error(fmt.Errorf("error following new blocks: %w", err))
+			}
+		}()
+	}
 
 	worker := grpcIndexer.NewIndexerServer(ctx, workerClient, logger.GetLogger())
 	grpcProtoIndexer.RegisterIndexerServiceServer(grpcServer, worker)
@@ -132,8 +200,12 @@ func main() {
 	attachProfiling(mux)
 
 	monitor := &health.Monitor{}
+	monitor.AddProber(ctx, api.NewBreakerProber(apiClient))
+	monitor.AddProber(ctx, api.NewSyncProber(apiClient, cfg.NodeMaxBlockLag))
 	go monitor.RunChecks(ctx, cfg.HealthCheckInterval)
 	monitor.AttachHttp(mux)
+	attachCosmosHealth(mux, apiClient)
+	attachDiskCache(mux, apiClient.DiskCache, cfg.CacheAdminToken)
 
 	attachDynamic(ctx, mux)
 
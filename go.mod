@@ -9,10 +9,12 @@ require (
 	github.com/figment-networks/indexing-engine v0.2.1
 	github.com/gogo/protobuf v1.3.3
 	github.com/google/uuid v1.2.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/gravity-devs/liquidity v1.2.9
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/rollbar/rollbar-go v1.2.0
 	github.com/stretchr/testify v1.7.0
+	github.com/syndtr/goleveldb v1.0.1-0.20200815110645-5c35d600f0ca
 	github.com/tendermint/tendermint v0.34.11
 	go.uber.org/zap v1.16.0
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
@@ -46,7 +46,7 @@ func TestGetBlock(t *testing.T) {
 			api.InitMetrics()
 			conn, err := grpc.Dial(tt.args.address, grpc.WithInsecure())
 			require.NoError(t, err)
-			cli := api.NewClient(zl, conn, &api.ClientConfig{
+			cli := api.NewClient(zl, []*grpc.ClientConn{conn}, &api.ClientConfig{
 				ReqPerSecond:        30,
 				TimeoutBlockCall:    time.Second * 60,
 				TimeoutSearchTxCall: time.Second * 60,
@@ -112,12 +112,12 @@ func TestGetResponseConsistency(t *testing.T) {
 			api.InitMetrics()
 			conn, err := grpc.Dial(tt.args.address, grpc.WithInsecure())
 			require.NoError(t, err)
-			apiClient := api.NewClient(zl, conn, &api.ClientConfig{
+			apiClient := api.NewClient(zl, []*grpc.ClientConn{conn}, &api.ClientConfig{
 				ReqPerSecond:        tt.args.reqsec,
 				TimeoutBlockCall:    time.Second * 60,
 				TimeoutSearchTxCall: time.Second * 60,
 			})
-			workerClient := client.NewIndexerClient(ctx, zl, apiClient, uint64(1000))
+			workerClient := client.NewIndexerClient(ctx, zl, apiClient, uint64(1000), client.Config{})
 
 			sr := newSendRegistry()
 			trp, _ := json.Marshal(tt.args.hRange)
@@ -237,7 +237,7 @@ func TestGetDelegatorReward(t *testing.T) {
 			conn, err := grpc.Dial(tt.address, grpc.WithInsecure())
 			require.NoError(t, err)
 
-			cli := api.NewClient(zl, conn, &api.ClientConfig{
+			cli := api.NewClient(zl, []*grpc.ClientConn{conn}, &api.ClientConfig{
 				ReqPerSecond:        30,
 				TimeoutBlockCall:    time.Second * 60,
 				TimeoutSearchTxCall: time.Second * 60,
@@ -295,7 +295,7 @@ func TestGetAccountBalance(t *testing.T) {
 			conn, err := grpc.Dial(tt.address, grpc.WithInsecure())
 			require.NoError(t, err)
 
-			cli := api.NewClient(zl, conn, &api.ClientConfig{
+			cli := api.NewClient(zl, []*grpc.ClientConn{conn}, &api.ClientConfig{
 				ReqPerSecond:        30,
 				TimeoutBlockCall:    time.Second * 60,
 				TimeoutSearchTxCall: time.Second * 60,